@@ -1,5 +1,145 @@
 package auth
 
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"github.com/iyarkov/kit/config"
+)
+
 type Configuration struct {
 	TrustedPeers []string
+	// UntrustedRoles roles the peers not listed in TrustedPeers are allowed to assert
+	UntrustedRoles []string
+	SigningKey     uint8
+	Keys           []Key
+	// TokenVersion format version of the issued tokens, the latest version by default
+	TokenVersion uint8
+	Roles        []RoleDefinition
+}
+
+// Key either HMAC key with base64 encoded Secret or Ed25519 key with PEM files. Services which only verify tokens
+// configure PublicKey, the issuer configures PrivateKey
+type Key struct {
+	Id         uint8
+	Secret     config.Password
+	PublicKey  string
+	PrivateKey string
+	Retired    bool
+}
+
+// InitAuth defines the roles and the token version, creates signer and verifier from the configuration and uses
+// them to sign and verify string tokens. The signer is not set when the signing key is an Ed25519 public key
+func InitAuth(cfg *Configuration) error {
+	signer, verifier, err := NewSignerAndVerifier(cfg)
+	if err != nil {
+		return err
+	}
+	version := cfg.TokenVersion
+	if version == 0 {
+		version = Version2
+	}
+	if err = SetTokenVersion(version); err != nil {
+		return err
+	}
+	if err = DefineRoles(cfg.Roles); err != nil {
+		return err
+	}
+	SetSigner(signer)
+	SetVerifier(verifier)
+	return nil
+}
+
+// NewSignerAndVerifier creates signer and verifier from the configuration keys without changing the package state.
+// The signer is nil when the signing key is an Ed25519 public key
+func NewSignerAndVerifier(cfg *Configuration) (Signer, Verifier, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, nil, fmt.Errorf("no keys configured")
+	}
+	hmacKeyring := NewHmacKeyring()
+	ed25519Verifier := NewEd25519Verifier()
+	var signer Signer
+	var signingKeyFound bool
+	var hmacKeys, ed25519Keys int
+	seen := make(map[uint8]bool, len(cfg.Keys))
+	for _, key := range cfg.Keys {
+		if seen[key.Id] {
+			return nil, nil, fmt.Errorf("key %d: duplicated key id", key.Id)
+		}
+		seen[key.Id] = true
+		if key.Id == cfg.SigningKey {
+			signingKeyFound = true
+		}
+		if key.Secret.Value() != "" {
+			secret, err := base64.StdEncoding.DecodeString(key.Secret.Value())
+			if err != nil {
+				return nil, nil, fmt.Errorf("key %d: failed to decode secret: %w", key.Id, err)
+			}
+			if err = hmacKeyring.AddKey(key.Id, secret); err != nil {
+				return nil, nil, err
+			}
+			if key.Id == cfg.SigningKey {
+				signer = hmacKeyring
+			}
+			hmacKeys++
+			continue
+		}
+
+		var publicKey ed25519.PublicKey
+		var err error
+		if key.PrivateKey != "" {
+			privateKey, err := LoadEd25519PrivateKey(key.PrivateKey)
+			if err != nil {
+				return nil, nil, err
+			}
+			publicKey = privateKey.Public().(ed25519.PublicKey)
+			if key.Id == cfg.SigningKey {
+				signer = NewEd25519Signer(key.Id, privateKey)
+			}
+		} else if key.PublicKey != "" {
+			if publicKey, err = LoadEd25519PublicKey(key.PublicKey); err != nil {
+				return nil, nil, err
+			}
+		} else {
+			return nil, nil, fmt.Errorf("key %d: either Secret, PublicKey or PrivateKey required", key.Id)
+		}
+		if err = ed25519Verifier.AddKey(key.Id, publicKey); err != nil {
+			return nil, nil, err
+		}
+		ed25519Keys++
+	}
+	if !signingKeyFound {
+		return nil, nil, fmt.Errorf("signing key %d is not configured", cfg.SigningKey)
+	}
+	if signer == hmacKeyring {
+		if err := hmacKeyring.Rotate(cfg.SigningKey); err != nil {
+			return nil, nil, fmt.Errorf("signing key %d: %w", cfg.SigningKey, err)
+		}
+	}
+	for _, key := range cfg.Keys {
+		if !key.Retired {
+			continue
+		}
+		if key.Id == cfg.SigningKey {
+			return nil, nil, fmt.Errorf("key %d is the signing key and can not be retired", key.Id)
+		}
+		var err error
+		if key.Secret.Value() != "" {
+			err = hmacKeyring.Retire(key.Id)
+		} else {
+			err = ed25519Verifier.Retire(key.Id)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	verifiers := make(Verifiers, 0, 2)
+	if hmacKeys > 0 {
+		verifiers = append(verifiers, hmacKeyring)
+	}
+	if ed25519Keys > 0 {
+		verifiers = append(verifiers, ed25519Verifier)
+	}
+	return signer, verifiers, nil
 }
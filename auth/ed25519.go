@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Ed25519Signer signs tokens with the issuer private key
+type Ed25519Signer struct {
+	keyId uint8
+	key   ed25519.PrivateKey
+}
+
+func NewEd25519Signer(keyId uint8, key ed25519.PrivateKey) *Ed25519Signer {
+	return &Ed25519Signer{
+		keyId: keyId,
+		key:   key,
+	}
+}
+
+func (s *Ed25519Signer) KeyId() uint8 {
+	return s.keyId
+}
+
+func (s *Ed25519Signer) Sign(payload []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, payload), nil
+}
+
+func (s *Ed25519Signer) SignWithKey(payload func(keyId uint8) []byte) (uint8, []byte, error) {
+	return s.keyId, ed25519.Sign(s.key, payload(s.keyId)), nil
+}
+
+// Ed25519Verifier verifies tokens with a set of issuer public keys
+type Ed25519Verifier struct {
+	mu      sync.RWMutex
+	keys    map[uint8]ed25519.PublicKey
+	retired map[uint8]bool
+}
+
+func NewEd25519Verifier() *Ed25519Verifier {
+	return &Ed25519Verifier{
+		keys:    make(map[uint8]ed25519.PublicKey),
+		retired: make(map[uint8]bool),
+	}
+}
+
+func (v *Ed25519Verifier) AddKey(id uint8, key ed25519.PublicKey) error {
+	if len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("key %d: invalid public key size %d", id, len(key))
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.keys[id]; ok {
+		return fmt.Errorf("key %d already registered", id)
+	}
+	v.keys[id] = key
+	return nil
+}
+
+// Retire rejects all tokens signed with the key
+func (v *Ed25519Verifier) Retire(id uint8) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.keys[id]; !ok {
+		return ErrorUnknownKey
+	}
+	v.retired[id] = true
+	return nil
+}
+
+func (v *Ed25519Verifier) Verify(keyId uint8, payload []byte, signature []byte) error {
+	v.mu.RLock()
+	key, ok := v.keys[keyId]
+	retired := v.retired[keyId]
+	v.mu.RUnlock()
+	if !ok {
+		return ErrorUnknownKey
+	}
+	if retired {
+		return ErrorRetiredKey
+	}
+	if !ed25519.Verify(key, payload, signature) {
+		return ErrorInvalidSignature
+	}
+	return nil
+}
+
+// Verifiers delegates verification to the verifier which knows the key
+type Verifiers []Verifier
+
+func (verifiers Verifiers) Verify(keyId uint8, payload []byte, signature []byte) error {
+	for _, verifier := range verifiers {
+		err := verifier.Verify(keyId, payload, signature)
+		if !errors.Is(err, ErrorUnknownKey) {
+			return err
+		}
+	}
+	return ErrorUnknownKey
+}
+
+// LoadEd25519PrivateKey reads PKCS #8 PEM encoded private key
+func LoadEd25519PrivateKey(fileName string) (ed25519.PrivateKey, error) {
+	block, err := readPem(fileName, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %w", fileName, err)
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key %s is not an Ed25519 key", fileName)
+	}
+	return privateKey, nil
+}
+
+// LoadEd25519PublicKey reads PKIX PEM encoded public key
+func LoadEd25519PublicKey(fileName string) (ed25519.PublicKey, error) {
+	block, err := readPem(fileName, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", fileName, err)
+	}
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key %s is not an Ed25519 key", fileName)
+	}
+	return publicKey, nil
+}
+
+func readPem(fileName string, blockType string) (*pem.Block, error) {
+	raw, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load key %s: %w", fileName, err)
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("key %s: %s PEM block not found", fileName, blockType)
+	}
+	return block, nil
+}
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"github.com/iyarkov/kit/config"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestEd25519Key(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	return publicKey, privateKey
+}
+
+func writeTestPem(t *testing.T, blockType string, der []byte) string {
+	fileName := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(fileName, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	return fileName
+}
+
+func TestEd25519SignVerify(t *testing.T) {
+	publicKey, privateKey := newTestEd25519Key(t)
+	signer := NewEd25519Signer(5, privateKey)
+	verifier := NewEd25519Verifier()
+	if err := verifier.AddKey(5, publicKey); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	buffer := signTestToken(t, signer)
+	if buffer[1] != 5 {
+		t.Errorf("Expecting key id 5 in the header, got %d", buffer[1])
+	}
+	var token Token
+	if err := token.ReadSigned(buffer, verifier); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	buffer[15] = byte(Admin)
+	if err := token.ReadSigned(buffer, verifier); err != ErrorInvalidSignature {
+		t.Errorf("Expecting ErrorInvalidSignature, got %v", err)
+	}
+}
+
+func TestEd25519UnknownAndRetiredKey(t *testing.T) {
+	publicKey, privateKey := newTestEd25519Key(t)
+	verifier := NewEd25519Verifier()
+	if err := verifier.AddKey(5, publicKey); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	var token Token
+	buffer := signTestToken(t, NewEd25519Signer(6, privateKey))
+	if err := token.ReadSigned(buffer, verifier); err != ErrorUnknownKey {
+		t.Errorf("Expecting ErrorUnknownKey, got %v", err)
+	}
+
+	if err := verifier.Retire(5); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	buffer = signTestToken(t, NewEd25519Signer(5, privateKey))
+	if err := token.ReadSigned(buffer, verifier); err != ErrorRetiredKey {
+		t.Errorf("Expecting ErrorRetiredKey, got %v", err)
+	}
+}
+
+func TestVerifiers(t *testing.T) {
+	publicKey, privateKey := newTestEd25519Key(t)
+	ed25519Verifier := NewEd25519Verifier()
+	if err := ed25519Verifier.AddKey(5, publicKey); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	keyring := newTestKeyring(t)
+	verifiers := Verifiers{keyring, ed25519Verifier}
+
+	var token Token
+	if err := token.ReadSigned(signTestToken(t, keyring), verifiers); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if err := token.ReadSigned(signTestToken(t, NewEd25519Signer(5, privateKey)), verifiers); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if err := token.ReadSigned(signTestToken(t, NewEd25519Signer(9, privateKey)), verifiers); err != ErrorUnknownKey {
+		t.Errorf("Expecting ErrorUnknownKey, got %v", err)
+	}
+}
+
+func TestLoadEd25519Keys(t *testing.T) {
+	publicKey, privateKey := newTestEd25519Key(t)
+	publicDer, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	privateDer, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	loadedPublic, err := LoadEd25519PublicKey(writeTestPem(t, "PUBLIC KEY", publicDer))
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+	} else if !loadedPublic.Equal(publicKey) {
+		t.Errorf("Public keys should match")
+	}
+	loadedPrivate, err := LoadEd25519PrivateKey(writeTestPem(t, "PRIVATE KEY", privateDer))
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+	} else if !loadedPrivate.Equal(privateKey) {
+		t.Errorf("Private keys should match")
+	}
+	if _, err = LoadEd25519PublicKey(writeTestPem(t, "PRIVATE KEY", privateDer)); err == nil {
+		t.Errorf("Private key must not be loaded as a public key")
+	}
+}
+
+func TestInitAuthIssuerAndVerifier(t *testing.T) {
+	publicKey, privateKey := newTestEd25519Key(t)
+	publicDer, _ := x509.MarshalPKIXPublicKey(publicKey)
+	privateDer, _ := x509.MarshalPKCS8PrivateKey(privateKey)
+	defer SetSigner(nil)
+	defer SetVerifier(nil)
+
+	// Issuer
+	err := InitAuth(&Configuration{
+		SigningKey: 3,
+		Keys: []Key{
+			{Id: 3, PrivateKey: writeTestPem(t, "PRIVATE KEY", privateDer)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	token := Token{
+		AccountId: 4,
+		GroupId:   12,
+		Role:      User,
+		ExpiresAt: time.Now().Add(time.Hour).Round(time.Second),
+	}
+	encoded, err := token.WriteToString()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	// Verifier, accepts both HMAC and Ed25519 tokens and can not sign
+	err = InitAuth(&Configuration{
+		SigningKey: 3,
+		Keys: []Key{
+			{Id: 1, Secret: configPassword(bytes.Repeat([]byte{1}, 32))},
+			{Id: 3, PublicKey: writeTestPem(t, "PUBLIC KEY", publicDer)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	tokenCopy, err := ReadFromString(encoded)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if !reflect.DeepEqual(*tokenCopy, token) {
+		t.Errorf("Tokens should match, token: %v, copy: %v", token, tokenCopy)
+	}
+	if _, err = token.WriteToString(); err != ErrorNoSigner {
+		t.Errorf("Expecting ErrorNoSigner, got %v", err)
+	}
+}
+
+func TestInitAuthDuplicatedKey(t *testing.T) {
+	err := InitAuth(&Configuration{
+		SigningKey: 1,
+		Keys: []Key{
+			{Id: 1, Secret: configPassword(bytes.Repeat([]byte{1}, 32))},
+			{Id: 1, Secret: configPassword(bytes.Repeat([]byte{2}, 32))},
+		},
+	})
+	if err == nil {
+		t.Errorf("Duplicated key ids must be rejected")
+	}
+}
+
+func configPassword(secret []byte) config.Password {
+	return config.NewPassword(base64.StdEncoding.EncodeToString(secret))
+}
@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Refresh token claims
+const (
+	ClaimTokenType       = "typ"
+	ClaimGeneration      = "gen"
+	TokenTypeRefresh     = "refresh"
+	refreshClaimsReserve = 2
+)
+
+var ErrorRefreshReused = errors.New("refresh token reused")
+var ErrorUnknownSession = errors.New("unknown session")
+
+// IsRefresh returns true for refresh tokens, they are not accepted as access tokens
+func (token *Token) IsRefresh() bool {
+	tokenType, _ := token.Claim(ClaimTokenType)
+	return tokenType == TokenTypeRefresh
+}
+
+// RefreshStore tracks the latest refresh token generation of each session. Presenting an older generation means
+// the refresh token was stolen, the whole session is revoked
+type RefreshStore interface {
+	Create(ctx context.Context, sessionId uuid.UUID, expiresAt time.Time) error
+	// Rotate moves the session to the next generation, returns ErrorRefreshReused if generation is not the latest
+	Rotate(ctx context.Context, sessionId uuid.UUID, generation uint32, expiresAt time.Time) error
+	Revoke(ctx context.Context, sessionId uuid.UUID) error
+}
+
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// Issuer mints short-lived access tokens and long-lived refresh tokens sharing the same SessionId
+type Issuer struct {
+	signer     Signer
+	verifier   Verifier
+	store      RefreshStore
+	accessTtl  time.Duration
+	refreshTtl time.Duration
+}
+
+func NewIssuer(signer Signer, verifier Verifier, store RefreshStore, accessTtl time.Duration, refreshTtl time.Duration) *Issuer {
+	return &Issuer{
+		signer:     signer,
+		verifier:   verifier,
+		store:      store,
+		accessTtl:  accessTtl,
+		refreshTtl: refreshTtl,
+	}
+}
+
+// Issue starts a new session for the account described by the token
+func (issuer *Issuer) Issue(ctx context.Context, token Token) (TokenPair, error) {
+	if !token.IsAuthenticated() {
+		return TokenPair{}, fmt.Errorf("can not issue tokens for anonymous")
+	}
+	if len(token.Claims) > MaxClaims-refreshClaimsReserve {
+		return TokenPair{}, fmt.Errorf("too many claims: %d", len(token.Claims))
+	}
+	for _, claim := range token.Claims {
+		if claim.Name == ClaimTokenType || claim.Name == ClaimGeneration {
+			return TokenPair{}, fmt.Errorf("claim %s is reserved", claim.Name)
+		}
+	}
+	token.SessionId = uuid.New()
+	refreshExpiresAt := time.Now().Add(issuer.refreshTtl)
+	if err := issuer.store.Create(ctx, token.SessionId, refreshExpiresAt); err != nil {
+		return TokenPair{}, fmt.Errorf("failed to create session: %w", err)
+	}
+	return issuer.issue(token, 0, refreshExpiresAt)
+}
+
+// Refresh validates the refresh token and rotates it. Reusing a rotated refresh token revokes the session
+func (issuer *Issuer) Refresh(ctx context.Context, refreshToken string) (TokenPair, error) {
+	buffer, err := base64.StdEncoding.DecodeString(refreshToken)
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("failed to decode string %w", err)
+	}
+	var token Token
+	if err = token.ReadSigned(buffer, issuer.verifier); err != nil {
+		return TokenPair{}, err
+	}
+	if !token.IsRefresh() {
+		return TokenPair{}, ErrorInvalidToken
+	}
+	if token.IsExpired() {
+		return TokenPair{}, ErrorExpiredToken
+	}
+	if isRevoked(&token) {
+		return TokenPair{}, ErrorRevokedToken
+	}
+	generationClaim, _ := token.Claim(ClaimGeneration)
+	generation, err := strconv.ParseUint(generationClaim, 10, 32)
+	if err != nil {
+		return TokenPair{}, ErrorInvalidToken
+	}
+
+	refreshExpiresAt := time.Now().Add(issuer.refreshTtl)
+	if err = issuer.store.Rotate(ctx, token.SessionId, uint32(generation), refreshExpiresAt); err != nil {
+		return TokenPair{}, err
+	}
+
+	claims := make([]Claim, 0, len(token.Claims))
+	for _, claim := range token.Claims {
+		if claim.Name != ClaimTokenType && claim.Name != ClaimGeneration {
+			claims = append(claims, claim)
+		}
+	}
+	token.Claims = claims
+	return issuer.issue(token, uint32(generation)+1, refreshExpiresAt)
+}
+
+func (issuer *Issuer) issue(token Token, generation uint32, refreshExpiresAt time.Time) (TokenPair, error) {
+	now := time.Now()
+	token.IssuedAt = now
+	token.ExpiresAt = now.Add(issuer.accessTtl)
+	accessBuffer, err := token.signVersion(issuer.signer, Version2)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	refresh := token
+	refresh.ExpiresAt = refreshExpiresAt
+	refresh.Claims = append(append([]Claim{}, token.Claims...),
+		Claim{Name: ClaimTokenType, Value: TokenTypeRefresh},
+		Claim{Name: ClaimGeneration, Value: strconv.FormatUint(uint64(generation), 10)},
+	)
+	refreshBuffer, err := refresh.signVersion(issuer.signer, Version2)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		AccessToken:      base64.StdEncoding.EncodeToString(accessBuffer),
+		AccessExpiresAt:  token.ExpiresAt,
+		RefreshToken:     base64.StdEncoding.EncodeToString(refreshBuffer),
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+type refreshSession struct {
+	generation uint32
+	expiresAt  time.Time
+	revoked    bool
+}
+
+// MemoryRefreshStore keeps sessions in memory, suitable for tests and single instance issuers
+type MemoryRefreshStore struct {
+	mu       sync.Mutex
+	sessions map[uuid.UUID]*refreshSession
+}
+
+func NewMemoryRefreshStore() *MemoryRefreshStore {
+	return &MemoryRefreshStore{
+		sessions: make(map[uuid.UUID]*refreshSession),
+	}
+}
+
+func (s *MemoryRefreshStore) Create(_ context.Context, sessionId uuid.UUID, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeExpired()
+	s.sessions[sessionId] = &refreshSession{
+		expiresAt: expiresAt,
+	}
+	return nil
+}
+
+func (s *MemoryRefreshStore) Rotate(_ context.Context, sessionId uuid.UUID, generation uint32, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[sessionId]
+	if !ok {
+		return ErrorUnknownSession
+	}
+	if session.revoked || session.generation != generation {
+		session.revoked = true
+		return ErrorRefreshReused
+	}
+	session.generation++
+	session.expiresAt = expiresAt
+	return nil
+}
+
+func (s *MemoryRefreshStore) Revoke(_ context.Context, sessionId uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[sessionId]
+	if !ok {
+		return ErrorUnknownSession
+	}
+	session.revoked = true
+	return nil
+}
+
+func (s *MemoryRefreshStore) removeExpired() {
+	now := time.Now()
+	for sessionId, session := range s.sessions {
+		if now.After(session.expiresAt) {
+			delete(s.sessions, sessionId)
+		}
+	}
+}
+
+// RefreshFunc exchanges the refresh token for a new token pair, usually a call to the issuer service
+type RefreshFunc func(ctx context.Context, refreshToken string) (TokenPair, error)
+
+// TokenSource keeps the client token pair and refreshes the access token before it expires
+type TokenSource struct {
+	mu      sync.Mutex
+	pair    TokenPair
+	refresh RefreshFunc
+	skew    time.Duration
+}
+
+// NewTokenSource refreshes the access token skew before it expires
+func NewTokenSource(pair TokenPair, refresh RefreshFunc, skew time.Duration) *TokenSource {
+	return &TokenSource{
+		pair:    pair,
+		refresh: refresh,
+		skew:    skew,
+	}
+}
+
+func (s *TokenSource) AccessToken(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Now().Add(s.skew).Before(s.pair.AccessExpiresAt) {
+		return s.pair.AccessToken, nil
+	}
+	pair, err := s.refresh(ctx, s.pair.RefreshToken)
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh access token: %w", err)
+	}
+	s.pair = pair
+	return s.pair.AccessToken, nil
+}
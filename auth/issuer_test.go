@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestIssuer(t *testing.T) *Issuer {
+	keyring := newTestKeyring(t)
+	SetSigner(keyring)
+	SetVerifier(keyring)
+	t.Cleanup(func() {
+		SetSigner(nil)
+		SetVerifier(nil)
+	})
+	return NewIssuer(keyring, keyring, NewMemoryRefreshStore(), time.Minute, time.Hour)
+}
+
+func TestIssuerIssue(t *testing.T) {
+	issuer := newTestIssuer(t)
+	ctx := context.Background()
+	pair, err := issuer.Issue(ctx, Token{
+		AccountId: 4,
+		GroupId:   12,
+		Role:      User,
+		Claims:    []Claim{{Name: "tenant", Value: "acme"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	access, err := ReadFromString(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if access.AccountId != 4 || access.IsRefresh() || access.IssuedAt.IsZero() {
+		t.Errorf("Unexpected access token %v", access)
+	}
+	if tenant, _ := access.Claim("tenant"); tenant != "acme" {
+		t.Errorf("Expecting tenant claim acme, got %s", tenant)
+	}
+	if !pair.AccessExpiresAt.Before(pair.RefreshExpiresAt) {
+		t.Errorf("Access token must expire before the refresh token")
+	}
+
+	if _, err = WithStringToken(ctx, pair.AccessToken); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if _, err = WithStringToken(ctx, pair.RefreshToken); err != ErrorInvalidToken {
+		t.Errorf("Refresh token must not be accepted as an access token, got %v", err)
+	}
+}
+
+func TestIssuerRefresh(t *testing.T) {
+	issuer := newTestIssuer(t)
+	ctx := context.Background()
+	pair, err := issuer.Issue(ctx, Token{AccountId: 4, Role: User})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	refreshed, err := issuer.Refresh(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	original, _ := ReadFromString(pair.AccessToken)
+	access, err := ReadFromString(refreshed.AccessToken)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if access.SessionId != original.SessionId || access.AccountId != 4 {
+		t.Errorf("Refreshed token must keep the session, original: %v, refreshed: %v", original, access)
+	}
+
+	if _, err = issuer.Refresh(ctx, refreshed.RefreshToken); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestIssuerRefreshReuse(t *testing.T) {
+	issuer := newTestIssuer(t)
+	ctx := context.Background()
+	pair, err := issuer.Issue(ctx, Token{AccountId: 4, Role: User})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	refreshed, err := issuer.Refresh(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	// Stolen token is reused, the whole session is revoked
+	if _, err = issuer.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrorRefreshReused) {
+		t.Errorf("Expecting ErrorRefreshReused, got %v", err)
+	}
+	if _, err = issuer.Refresh(ctx, refreshed.RefreshToken); !errors.Is(err, ErrorRefreshReused) {
+		t.Errorf("Expecting ErrorRefreshReused for the revoked session, got %v", err)
+	}
+}
+
+func TestIssuerRefreshWithAccessToken(t *testing.T) {
+	issuer := newTestIssuer(t)
+	ctx := context.Background()
+	pair, err := issuer.Issue(ctx, Token{AccountId: 4, Role: User})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if _, err = issuer.Refresh(ctx, pair.AccessToken); err != ErrorInvalidToken {
+		t.Errorf("Expecting ErrorInvalidToken, got %v", err)
+	}
+}
+
+func TestIssuerReservedClaim(t *testing.T) {
+	issuer := newTestIssuer(t)
+	_, err := issuer.Issue(context.Background(), Token{
+		AccountId: 4,
+		Role:      User,
+		Claims:    []Claim{{Name: ClaimTokenType, Value: TokenTypeRefresh}},
+	})
+	if err == nil {
+		t.Errorf("Reserved claims must be rejected")
+	}
+}
+
+func TestTokenSource(t *testing.T) {
+	issuer := newTestIssuer(t)
+	ctx := context.Background()
+	pair, err := issuer.Issue(ctx, Token{AccountId: 4, Role: User})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	refreshCount := 0
+	source := NewTokenSource(pair, func(ctx context.Context, refreshToken string) (TokenPair, error) {
+		refreshCount++
+		return issuer.Refresh(ctx, refreshToken)
+	}, 30*time.Second)
+
+	accessToken, err := source.AccessToken(ctx)
+	if err != nil || accessToken != pair.AccessToken || refreshCount != 0 {
+		t.Errorf("Valid access token must not be refreshed, refreshed %d times, error %v", refreshCount, err)
+	}
+
+	// Access token is about to expire
+	source.skew = 2 * time.Minute
+	accessToken, err = source.AccessToken(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if refreshCount != 1 {
+		t.Errorf("Access token must be refreshed once, refreshed %d times", refreshCount)
+	}
+	if _, err = ReadFromString(accessToken); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrorRevokedToken = errors.New("revoked token")
+
+// RevocationStore persists revocations. Each revocation rejects the matching tokens issued before the given time
+type RevocationStore interface {
+	RevokeAccount(ctx context.Context, accountId uint64, issuedBefore time.Time) error
+	RevokeGroup(ctx context.Context, groupId uint32, issuedBefore time.Time) error
+	RevokeIssuedBefore(ctx context.Context, issuedBefore time.Time) error
+	Load(ctx context.Context) (*RevocationList, error)
+}
+
+// RevocationList snapshot of the revocations. Tokens without IssuedAt (Version1) are revoked by any matching
+// revocation
+type RevocationList struct {
+	Accounts     map[uint64]time.Time
+	Groups       map[uint32]time.Time
+	IssuedBefore time.Time
+}
+
+func NewRevocationList() *RevocationList {
+	return &RevocationList{
+		Accounts: make(map[uint64]time.Time),
+		Groups:   make(map[uint32]time.Time),
+	}
+}
+
+func (list *RevocationList) IsRevoked(token *Token) bool {
+	if !token.IsAuthenticated() {
+		return false
+	}
+	if issuedBefore(token, list.IssuedBefore) {
+		return true
+	}
+	if revokedAt, ok := list.Accounts[token.AccountId]; ok && issuedBefore(token, revokedAt) {
+		return true
+	}
+	if revokedAt, ok := list.Groups[token.GroupId]; ok && issuedBefore(token, revokedAt) {
+		return true
+	}
+	return false
+}
+
+// issuedBefore IssuedAt has one second resolution, so the times are compared in seconds and a token issued in the same
+// second as the revocation is revoked
+func issuedBefore(token *Token, revokedAt time.Time) bool {
+	if revokedAt.IsZero() {
+		return false
+	}
+	return !token.IssuedAt.Truncate(time.Second).After(revokedAt.Truncate(time.Second))
+}
+
+func laterTime(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// MemoryRevocationStore keeps revocations in memory, suitable for tests and single instance services
+type MemoryRevocationStore struct {
+	mu   sync.Mutex
+	list *RevocationList
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		list: NewRevocationList(),
+	}
+}
+
+func (s *MemoryRevocationStore) RevokeAccount(_ context.Context, accountId uint64, issuedBefore time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.list.Accounts[accountId] = laterTime(s.list.Accounts[accountId], issuedBefore)
+	return nil
+}
+
+func (s *MemoryRevocationStore) RevokeGroup(_ context.Context, groupId uint32, issuedBefore time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.list.Groups[groupId] = laterTime(s.list.Groups[groupId], issuedBefore)
+	return nil
+}
+
+func (s *MemoryRevocationStore) RevokeIssuedBefore(_ context.Context, issuedBefore time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.list.IssuedBefore = laterTime(s.list.IssuedBefore, issuedBefore)
+	return nil
+}
+
+func (s *MemoryRevocationStore) Load(_ context.Context) (*RevocationList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := NewRevocationList()
+	for accountId, issuedBefore := range s.list.Accounts {
+		result.Accounts[accountId] = issuedBefore
+	}
+	for groupId, issuedBefore := range s.list.Groups {
+		result.Groups[groupId] = issuedBefore
+	}
+	result.IssuedBefore = s.list.IssuedBefore
+	return result, nil
+}
+
+// RevocationCache local copy of the store revocations, refreshed periodically
+type RevocationCache struct {
+	store RevocationStore
+	list  atomic.Pointer[RevocationList]
+}
+
+// NewRevocationCache loads the revocations and refreshes them every refresh interval until the context is done
+func NewRevocationCache(ctx context.Context, store RevocationStore, refresh time.Duration) (*RevocationCache, error) {
+	cache := RevocationCache{
+		store: store,
+	}
+	if err := cache.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	go func() {
+		log := zerolog.Ctx(ctx)
+		ticker := time.NewTicker(refresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := cache.Refresh(ctx); err != nil {
+					log.Error().Err(err).Msg("failed to refresh token revocations")
+				}
+			case <-ctx.Done():
+				log.Debug().Msg("token revocations refresh stopped")
+				return
+			}
+		}
+	}()
+	return &cache, nil
+}
+
+// Refresh reloads the revocations from the store
+func (cache *RevocationCache) Refresh(ctx context.Context) error {
+	list, err := cache.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	cache.list.Store(list)
+	return nil
+}
+
+func (cache *RevocationCache) IsRevoked(token *Token) bool {
+	return cache.list.Load().IsRevoked(token)
+}
+
+var defaultRevocations atomic.Pointer[RevocationCache]
+
+// SetRevocationCache sets the cache checked by WithStringToken, nil disables the check
+func SetRevocationCache(cache *RevocationCache) {
+	defaultRevocations.Store(cache)
+}
+
+func isRevoked(token *Token) bool {
+	cache := defaultRevocations.Load()
+	return cache != nil && cache.IsRevoked(token)
+}
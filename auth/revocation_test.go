@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRevocationList(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryRevocationStore()
+	ctx := context.Background()
+	_ = store.RevokeAccount(ctx, 4, now)
+	_ = store.RevokeGroup(ctx, 12, now.Add(-time.Hour))
+	_ = store.RevokeIssuedBefore(ctx, now.Add(-2*time.Hour))
+	list, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	type spec struct {
+		name     string
+		token    Token
+		expected bool
+	}
+	suite := []spec{
+		{"account revoked", Token{AccountId: 4, Role: User, IssuedAt: now.Add(-time.Minute)}, true},
+		{"account issued after", Token{AccountId: 4, Role: User, IssuedAt: now.Add(time.Minute)}, false},
+		{"group revoked", Token{AccountId: 5, GroupId: 12, Role: User, IssuedAt: now.Add(-90 * time.Minute)}, true},
+		{"group issued after", Token{AccountId: 5, GroupId: 12, Role: User, IssuedAt: now.Add(-30 * time.Minute)}, false},
+		{"all revoked", Token{AccountId: 6, Role: User, IssuedAt: now.Add(-3 * time.Hour)}, true},
+		{"not revoked", Token{AccountId: 6, Role: User, IssuedAt: now.Add(-time.Minute)}, false},
+		{"version 1", Token{AccountId: 6, Role: User}, true},
+		{"same second", Token{AccountId: 4, Role: User, IssuedAt: time.Unix(now.Unix(), 0)}, true},
+		{"anonymous", Token{}, false},
+	}
+	for _, test := range suite {
+		t.Run(test.name, func(t *testing.T) {
+			if list.IsRevoked(&test.token) != test.expected {
+				t.Errorf("Expecting revoked %t", test.expected)
+			}
+		})
+	}
+}
+
+func TestRevocationListSubSecond(t *testing.T) {
+	revokedAt := time.Unix(1700000000, 700_000_000)
+	list := NewRevocationList()
+	list.Accounts[4] = revokedAt
+	token := Token{AccountId: 4, Role: User, IssuedAt: revokedAt.Add(-200 * time.Millisecond)}
+	parsed := token
+	parsed.IssuedAt = time.Unix(token.IssuedAt.Unix(), 0)
+	if !list.IsRevoked(&token) || !list.IsRevoked(&parsed) {
+		t.Errorf("Expecting the token issued in the revocation second to be revoked")
+	}
+	next := Token{AccountId: 4, Role: User, IssuedAt: time.Unix(revokedAt.Unix()+1, 0)}
+	if list.IsRevoked(&next) {
+		t.Errorf("Expecting the token issued in the next second not to be revoked")
+	}
+	if NewRevocationList().IsRevoked(&Token{AccountId: 6, Role: User}) {
+		t.Errorf("Expecting an empty list not to revoke the version 1 token")
+	}
+}
+
+func TestMemoryRevocationStoreKeepsLatest(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryRevocationStore()
+	ctx := context.Background()
+	_ = store.RevokeAccount(ctx, 4, now)
+	_ = store.RevokeAccount(ctx, 4, now.Add(-time.Hour))
+	list, _ := store.Load(ctx)
+	if !list.Accounts[4].Equal(now) {
+		t.Errorf("Expecting the latest revocation %v, got %v", now, list.Accounts[4])
+	}
+}
+
+func TestWithStringTokenRevoked(t *testing.T) {
+	keyring := newTestKeyring(t)
+	SetSigner(keyring)
+	SetVerifier(keyring)
+	defer SetSigner(nil)
+	defer SetVerifier(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := NewMemoryRevocationStore()
+	cache, err := NewRevocationCache(ctx, store, time.Hour)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	SetRevocationCache(cache)
+	defer SetRevocationCache(nil)
+
+	token := Token{
+		AccountId: 4,
+		Role:      User,
+		ExpiresAt: time.Now().Add(time.Hour),
+		IssuedAt:  time.Now().Add(-time.Minute),
+	}
+	encoded, err := token.WriteToString()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if _, err = WithStringToken(ctx, encoded); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	_ = store.RevokeAccount(ctx, 4, time.Now())
+	if _, err = WithStringToken(ctx, encoded); err != nil {
+		t.Errorf("Revocation must not be visible before refresh, got %v", err)
+	}
+	if err = cache.Refresh(ctx); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if _, err = WithStringToken(ctx, encoded); err != ErrorRevokedToken {
+		t.Errorf("Expecting ErrorRevokedToken, got %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Permission named permission, e.g. "orders.read"
+type Permission string
+
+// RoleDefinition declarative role definition. Inherits lists role names, the role gets all their permissions
+type RoleDefinition struct {
+	Id          Role
+	Name        string
+	Inherits    []string
+	Permissions []Permission
+}
+
+type roleInfo struct {
+	name        string
+	inherits    []Role
+	permissions map[Permission]bool
+}
+
+var rolesMu sync.RWMutex
+var roles = make(map[Role]*roleInfo)
+var roleNames = make(map[string]Role)
+
+func init() {
+	for _, definition := range []struct {
+		role     Role
+		name     string
+		inherits []Role
+	}{
+		{Anonymous, "anonymous", nil},
+		{User, "user", nil},
+		{Manager, "manager", []Role{User}},
+		{Admin, "admin", []Role{Manager}},
+		{Candidate, "candidate", nil},
+		{Guest, "guest", nil},
+		{Operator, "operator", nil},
+	} {
+		if err := RegisterRole(definition.role, definition.name, definition.inherits...); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterRole registers an application role. Inherited roles must be registered before
+func RegisterRole(role Role, name string, inherits ...Role) error {
+	rolesMu.Lock()
+	defer rolesMu.Unlock()
+	if name == "" {
+		return fmt.Errorf("role %d: name required", role)
+	}
+	if existing, ok := roles[role]; ok {
+		return fmt.Errorf("role %d already registered as %s", role, existing.name)
+	}
+	if _, ok := roleNames[name]; ok {
+		return fmt.Errorf("role %s already registered", name)
+	}
+	for _, parent := range inherits {
+		if _, ok := roles[parent]; !ok {
+			return fmt.Errorf("role %s: inherited role %d is not registered", name, parent)
+		}
+	}
+	roles[role] = &roleInfo{
+		name:        name,
+		inherits:    append([]Role{}, inherits...),
+		permissions: make(map[Permission]bool),
+	}
+	roleNames[name] = role
+	return nil
+}
+
+// Inherit adds inherited roles to a registered role
+func Inherit(role Role, inherits ...Role) error {
+	rolesMu.Lock()
+	defer rolesMu.Unlock()
+	info, ok := roles[role]
+	if !ok {
+		return fmt.Errorf("role %d is not registered", role)
+	}
+	for _, parent := range inherits {
+		if _, ok = roles[parent]; !ok {
+			return fmt.Errorf("role %s: inherited role %d is not registered", info.name, parent)
+		}
+		if implies(role, parent) {
+			// Already inherited
+			continue
+		}
+		if implies(parent, role) {
+			return fmt.Errorf("role %s: inheriting role %d creates a cycle", info.name, parent)
+		}
+		info.inherits = append(info.inherits, parent)
+	}
+	return nil
+}
+
+// Grant grants permissions to a registered role
+func Grant(role Role, permissions ...Permission) error {
+	rolesMu.Lock()
+	defer rolesMu.Unlock()
+	info, ok := roles[role]
+	if !ok {
+		return fmt.Errorf("role %d is not registered", role)
+	}
+	for _, permission := range permissions {
+		info.permissions[permission] = true
+	}
+	return nil
+}
+
+// DefineRoles applies role definitions in order. Unknown roles are registered, known roles get additional
+// inherited roles and permissions
+func DefineRoles(definitions []RoleDefinition) error {
+	for _, definition := range definitions {
+		role, known := RoleByName(definition.Name)
+		if !known {
+			role = definition.Id
+			if err := RegisterRole(role, definition.Name); err != nil {
+				return err
+			}
+		}
+		inherits := make([]Role, 0, len(definition.Inherits))
+		for _, name := range definition.Inherits {
+			parent, ok := RoleByName(name)
+			if !ok {
+				return fmt.Errorf("role %s: inherited role %s is not registered", definition.Name, name)
+			}
+			inherits = append(inherits, parent)
+		}
+		if err := Inherit(role, inherits...); err != nil {
+			return err
+		}
+		if err := Grant(role, definition.Permissions...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func RoleByName(name string) (Role, bool) {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+	role, ok := roleNames[name]
+	return role, ok
+}
+
+func (role Role) String() string {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+	if info, ok := roles[role]; ok {
+		return info.name
+	}
+	return fmt.Sprintf("role(%d)", role)
+}
+
+// Implies returns true if the role is the other role or inherits it
+func (role Role) Implies(other Role) bool {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+	return implies(role, other)
+}
+
+// Can returns true if the role or any inherited role is granted the permission
+func (role Role) Can(permission Permission) bool {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+	return can(role, permission)
+}
+
+func implies(role Role, other Role) bool {
+	if role == other {
+		return true
+	}
+	info, ok := roles[role]
+	if !ok {
+		return false
+	}
+	for _, parent := range info.inherits {
+		if implies(parent, other) {
+			return true
+		}
+	}
+	return false
+}
+
+func can(role Role, permission Permission) bool {
+	info, ok := roles[role]
+	if !ok {
+		return false
+	}
+	if info.permissions[permission] {
+		return true
+	}
+	for _, parent := range info.inherits {
+		if can(parent, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole returns true if the token role is the role or inherits it, see IsInRole for the exact match
+func (token *Token) HasRole(role Role) bool {
+	return token.Role.Implies(role)
+}
+
+// Can returns true if the token role is granted the permission
+func (token *Token) Can(permission Permission) bool {
+	return token.Role.Can(permission)
+}
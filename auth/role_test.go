@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"testing"
+)
+
+func TestBuiltInRoleHierarchy(t *testing.T) {
+	type spec struct {
+		role     Role
+		other    Role
+		expected bool
+	}
+	suite := []spec{
+		{Admin, Admin, true},
+		{Admin, Manager, true},
+		{Admin, User, true},
+		{Manager, User, true},
+		{Manager, Admin, false},
+		{User, Manager, false},
+		{Operator, User, false},
+		{Guest, User, false},
+		{Anonymous, User, false},
+	}
+	for _, test := range suite {
+		t.Run(test.role.String()+"-"+test.other.String(), func(t *testing.T) {
+			if test.role.Implies(test.other) != test.expected {
+				t.Errorf("%s implies %s, expecting %t", test.role, test.other, test.expected)
+			}
+			token := Token{Role: test.role}
+			if token.HasRole(test.other) != test.expected {
+				t.Errorf("token %s has role %s, expecting %t", test.role, test.other, test.expected)
+			}
+		})
+	}
+}
+
+func TestRolePermissions(t *testing.T) {
+	err := DefineRoles([]RoleDefinition{
+		{Name: "user", Permissions: []Permission{"test.perm.read"}},
+		{Name: "manager", Permissions: []Permission{"test.perm.write"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	admin := Token{Role: Admin}
+	if !admin.Can("test.perm.read") || !admin.Can("test.perm.write") {
+		t.Errorf("Admin must inherit permissions")
+	}
+	user := Token{Role: User}
+	if !user.Can("test.perm.read") {
+		t.Errorf("User must have read permission")
+	}
+	if user.Can("test.perm.write") {
+		t.Errorf("User must not have write permission")
+	}
+	operator := Token{Role: Operator}
+	if operator.Can("test.perm.read") {
+		t.Errorf("Operator must not have read permission")
+	}
+}
+
+func TestCustomRole(t *testing.T) {
+	err := DefineRoles([]RoleDefinition{
+		{Id: 100, Name: "test.auditor", Inherits: []string{"guest"}, Permissions: []Permission{"test.audit"}},
+		{Id: 101, Name: "test.lead", Inherits: []string{"test.auditor", "user"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	lead, ok := RoleByName("test.lead")
+	if !ok || lead != 101 {
+		t.Fatalf("Role test.lead must be registered as 101, got %d", lead)
+	}
+	token := Token{Role: lead}
+	if !token.Can("test.audit") {
+		t.Errorf("Lead must inherit auditor permissions")
+	}
+	if !token.HasRole(Guest) || !token.HasRole(User) {
+		t.Errorf("Lead must inherit guest and user roles")
+	}
+	if token.IsInRole(User) {
+		t.Errorf("IsInRole must be the exact match")
+	}
+	if lead.String() != "test.lead" {
+		t.Errorf("Unexpected role name %s", lead)
+	}
+}
+
+func TestRoleErrors(t *testing.T) {
+	if err := RegisterRole(Admin, "test.admin"); err == nil {
+		t.Errorf("Role id must be unique")
+	}
+	if err := RegisterRole(110, "admin"); err == nil {
+		t.Errorf("Role name must be unique")
+	}
+	if err := RegisterRole(111, "test.orphan", 999); err == nil {
+		t.Errorf("Inherited role must be registered")
+	}
+	if err := Inherit(User, Admin); err == nil {
+		t.Errorf("Cycles must be rejected")
+	}
+	if err := DefineRoles([]RoleDefinition{{Name: "user", Inherits: []string{"missing"}}}); err == nil {
+		t.Errorf("Inherited role must be registered")
+	}
+}
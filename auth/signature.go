@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrorInvalidSignature = errors.New("invalid token signature")
+var ErrorUnknownKey = errors.New("unknown token key")
+var ErrorRetiredKey = errors.New("retired token key")
+
+// Signer signs the binary token payload. The key id is embedded into the token header so the verifier can find the
+// key, SignWithKey builds the payload for the signing key id and signs it with the same key
+type Signer interface {
+	SignWithKey(payload func(keyId uint8) []byte) (uint8, []byte, error)
+}
+
+// Verifier checks the signature of the binary token payload signed with the key keyId
+type Verifier interface {
+	Verify(keyId uint8, payload []byte, signature []byte) error
+}
+
+type hmacKey struct {
+	secret  []byte
+	retired bool
+}
+
+// HmacKeyring signs and verifies tokens with HMAC-SHA256. New tokens are signed with the current key, tokens signed
+// with any other known key are accepted until the key is retired
+type HmacKeyring struct {
+	mu      sync.RWMutex
+	keys    map[uint8]hmacKey
+	current uint8
+	active  bool
+}
+
+func NewHmacKeyring() *HmacKeyring {
+	return &HmacKeyring{
+		keys: make(map[uint8]hmacKey),
+	}
+}
+
+func (k *HmacKeyring) AddKey(id uint8, secret []byte) error {
+	if len(secret) < sha256.Size {
+		return fmt.Errorf("key %d: secret must be at least %d bytes", id, sha256.Size)
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; ok {
+		return fmt.Errorf("key %d already registered", id)
+	}
+	k.keys[id] = hmacKey{
+		secret: secret,
+	}
+	return nil
+}
+
+// Rotate makes the key the current signing key
+func (k *HmacKeyring) Rotate(id uint8) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	key, ok := k.keys[id]
+	if !ok {
+		return ErrorUnknownKey
+	}
+	if key.retired {
+		return ErrorRetiredKey
+	}
+	k.current = id
+	k.active = true
+	return nil
+}
+
+// Retire rejects all tokens signed with the key. The current signing key can not be retired
+func (k *HmacKeyring) Retire(id uint8) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	key, ok := k.keys[id]
+	if !ok {
+		return ErrorUnknownKey
+	}
+	if k.active && k.current == id {
+		return fmt.Errorf("key %d is the current signing key", id)
+	}
+	key.retired = true
+	k.keys[id] = key
+	return nil
+}
+
+func (k *HmacKeyring) KeyId() uint8 {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current
+}
+
+func (k *HmacKeyring) Sign(payload []byte) ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if !k.active {
+		return nil, errors.New("signing key is not set")
+	}
+	return k.sign(k.keys[k.current].secret, payload), nil
+}
+
+// SignWithKey signs under one lock, a concurrent Rotate can not mix the key id of one key with the signature of
+// another
+func (k *HmacKeyring) SignWithKey(payload func(keyId uint8) []byte) (uint8, []byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if !k.active {
+		return 0, nil, errors.New("signing key is not set")
+	}
+	return k.current, k.sign(k.keys[k.current].secret, payload(k.current)), nil
+}
+
+func (k *HmacKeyring) Verify(keyId uint8, payload []byte, signature []byte) error {
+	k.mu.RLock()
+	key, ok := k.keys[keyId]
+	k.mu.RUnlock()
+	if !ok {
+		return ErrorUnknownKey
+	}
+	if key.retired {
+		return ErrorRetiredKey
+	}
+	if !hmac.Equal(signature, k.sign(key.secret, payload)) {
+		return ErrorInvalidSignature
+	}
+	return nil
+}
+
+func (k *HmacKeyring) sign(secret []byte, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func newTestKeyring(t *testing.T) *HmacKeyring {
+	keyring := NewHmacKeyring()
+	if err := keyring.AddKey(1, bytes.Repeat([]byte{1}, 32)); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := keyring.AddKey(2, bytes.Repeat([]byte{2}, 32)); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := keyring.Rotate(1); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	return keyring
+}
+
+func signTestToken(t *testing.T, signer Signer) []byte {
+	token := Token{
+		AccountId: 4,
+		GroupId:   12,
+		Role:      Operator,
+		ExpiresAt: time.Unix(1686841129, 0),
+	}
+	buffer, err := token.Sign(signer)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	return buffer
+}
+
+func TestHmacSignVerify(t *testing.T) {
+	keyring := newTestKeyring(t)
+	buffer := signTestToken(t, keyring)
+	if buffer[1] != 1 {
+		t.Errorf("Expecting key id 1 in the header, got %d", buffer[1])
+	}
+
+	var token Token
+	if err := token.ReadSigned(buffer, keyring); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if token.AccountId != 4 || token.GroupId != 12 || token.Role != Operator {
+		t.Errorf("Unexpected token %v", token)
+	}
+}
+
+func TestHmacTamperedPayload(t *testing.T) {
+	keyring := newTestKeyring(t)
+	buffer := signTestToken(t, keyring)
+	buffer[15] = byte(Admin)
+
+	var token Token
+	if err := token.ReadSigned(buffer, keyring); err != ErrorInvalidSignature {
+		t.Errorf("Expecting ErrorInvalidSignature, got %v", err)
+	}
+}
+
+func TestHmacMissingSignature(t *testing.T) {
+	keyring := newTestKeyring(t)
+	buffer := signTestToken(t, keyring)
+
+	var token Token
+	if err := token.ReadSigned(buffer[:26], keyring); err != ErrorInvalidToken {
+		t.Errorf("Expecting ErrorInvalidToken, got %v", err)
+	}
+}
+
+func TestHmacUnknownKey(t *testing.T) {
+	keyring := newTestKeyring(t)
+	buffer := signTestToken(t, keyring)
+	buffer[1] = 7
+
+	var token Token
+	if err := token.ReadSigned(buffer, keyring); err != ErrorUnknownKey {
+		t.Errorf("Expecting ErrorUnknownKey, got %v", err)
+	}
+}
+
+func TestHmacRotation(t *testing.T) {
+	keyring := newTestKeyring(t)
+	oldToken := signTestToken(t, keyring)
+
+	if err := keyring.Rotate(2); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	newToken := signTestToken(t, keyring)
+	if newToken[1] != 2 {
+		t.Errorf("Expecting key id 2 in the header, got %d", newToken[1])
+	}
+
+	var token Token
+	if err := token.ReadSigned(oldToken, keyring); err != nil {
+		t.Errorf("Token signed with the previous key must be valid, got %v", err)
+	}
+	if err := token.ReadSigned(newToken, keyring); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	if err := keyring.Retire(1); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := token.ReadSigned(oldToken, keyring); err != ErrorRetiredKey {
+		t.Errorf("Expecting ErrorRetiredKey, got %v", err)
+	}
+	if err := token.ReadSigned(newToken, keyring); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestHmacConcurrentRotation(t *testing.T) {
+	keyring := newTestKeyring(t)
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			_ = keyring.Rotate(uint8(i%2 + 1))
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		var token Token
+		if err := token.ReadSigned(signTestToken(t, keyring), keyring); err != nil {
+			t.Fatalf("Token signed during the rotation, unexpected error %v", err)
+		}
+	}
+	<-done
+}
+
+func TestHmacRetireCurrentKey(t *testing.T) {
+	keyring := newTestKeyring(t)
+	if err := keyring.Retire(1); err == nil {
+		t.Errorf("Current key must not be retired")
+	}
+}
+
+func TestHmacShortSecret(t *testing.T) {
+	keyring := NewHmacKeyring()
+	if err := keyring.AddKey(1, []byte("secret")); err == nil {
+		t.Errorf("Short secret must be rejected")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"github.com/google/uuid"
 	"time"
 )
 
@@ -19,10 +20,30 @@ const (
 	Operator
 )
 
+// Token format versions. Version2 adds IssuedAt, SessionId and custom claims, readers accept all versions
+const (
+	Version1 uint8 = 1
+	Version2 uint8 = 2
+)
+
+// MaxClaims maximum number of custom claims, claim names and values are limited to 255 bytes
+const MaxClaims = 16
+
+const maxClaimLength = 255
+const version1Size = 26
+const version2Size = 51
+
 var ErrorInvalidToken = errors.New("invalid token")
 var ErrorExpiredToken = errors.New("expired token")
+var ErrorNoSigner = errors.New("token signer is not configured")
+var ErrorNoVerifier = errors.New("token verifier is not configured")
 
 type authTokenCtxKey struct{}
+type encodedTokenCtxKey struct{}
+
+var defaultSigner Signer
+var defaultVerifier Verifier
+var tokenVersion = Version2
 
 var bytOrder binary.ByteOrder = binary.BigEndian
 
@@ -33,6 +54,14 @@ type Token struct {
 	GroupId   uint32
 	Role      Role
 	ExpiresAt time.Time
+	IssuedAt  time.Time
+	SessionId uuid.UUID
+	Claims    []Claim
+}
+
+type Claim struct {
+	Name  string
+	Value string
 }
 
 func (token *Token) IsInRole(role Role) bool {
@@ -43,30 +72,141 @@ func (token *Token) IsAuthenticated() bool {
 	return token.Role != Anonymous
 }
 
-func (token *Token) Write() [26]byte {
-	var buffer [26]byte
-	buffer[0] = 1
+// Write writes the token using the version configured by SetTokenVersion
+func (token *Token) Write() ([]byte, error) {
+	return token.WriteVersion(tokenVersion)
+}
+
+// WriteVersion writes the token in the given format version. Version1 does not carry IssuedAt, SessionId and Claims
+func (token *Token) WriteVersion(version uint8) ([]byte, error) {
+	size := version1Size
+	switch version {
+	case Version1:
+	case Version2:
+		if len(token.Claims) > MaxClaims {
+			return nil, fmt.Errorf("too many claims: %d", len(token.Claims))
+		}
+		size = version2Size
+		for _, claim := range token.Claims {
+			if claim.Name == "" || len(claim.Name) > maxClaimLength || len(claim.Value) > maxClaimLength {
+				return nil, fmt.Errorf("invalid claim %s", claim.Name)
+			}
+			size += 2 + len(claim.Name) + len(claim.Value)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported token version %d", version)
+	}
+
+	buffer := make([]byte, size)
+	buffer[0] = version
 	bytOrder.PutUint64(buffer[2:10], token.AccountId)
 	bytOrder.PutUint32(buffer[10:14], token.GroupId)
 	bytOrder.PutUint16(buffer[14:18], uint16(token.Role))
 	bytOrder.PutUint64(buffer[18:26], uint64(token.ExpiresAt.Unix()))
-	return buffer
+	if version == Version1 {
+		return buffer, nil
+	}
+
+	if !token.IssuedAt.IsZero() {
+		bytOrder.PutUint64(buffer[26:34], uint64(token.IssuedAt.Unix()))
+	}
+	copy(buffer[34:50], token.SessionId[:])
+	buffer[50] = uint8(len(token.Claims))
+	offset := version2Size
+	for _, claim := range token.Claims {
+		offset = writeClaimString(buffer, offset, claim.Name)
+		offset = writeClaimString(buffer, offset, claim.Value)
+	}
+	return buffer, nil
+}
+
+func writeClaimString(buffer []byte, offset int, value string) int {
+	buffer[offset] = uint8(len(value))
+	offset++
+	return offset + copy(buffer[offset:], value)
 }
 
+// Read reads the token of any supported version, the buffer must contain exactly one token
 func (token *Token) Read(buffer []byte) error {
-	if len(buffer) != 26 {
-		return ErrorInvalidToken
+	size, err := token.read(buffer)
+	if err != nil {
+		return err
 	}
-	if buffer[0] != 1 || buffer[1] != 0 {
+	if size != len(buffer) {
 		return ErrorInvalidToken
 	}
-	token.AccountId = bytOrder.Uint64(buffer[2:10])
-	token.GroupId = bytOrder.Uint32(buffer[10:14])
-	token.Role = Role(bytOrder.Uint16(buffer[14:18]))
-	token.ExpiresAt = time.Unix(int64(bytOrder.Uint64(buffer[18:26])), 0)
 	return nil
 }
 
+// read reads the token from the beginning of the buffer and returns the token size
+func (token *Token) read(buffer []byte) (int, error) {
+	if len(buffer) < version1Size {
+		return 0, ErrorInvalidToken
+	}
+	version := buffer[0]
+	if version != Version1 && version != Version2 {
+		return 0, ErrorInvalidToken
+	}
+	result := Token{
+		AccountId: bytOrder.Uint64(buffer[2:10]),
+		GroupId:   bytOrder.Uint32(buffer[10:14]),
+		Role:      Role(bytOrder.Uint16(buffer[14:18])),
+		ExpiresAt: time.Unix(int64(bytOrder.Uint64(buffer[18:26])), 0),
+	}
+	if version == Version1 {
+		*token = result
+		return version1Size, nil
+	}
+
+	if len(buffer) < version2Size {
+		return 0, ErrorInvalidToken
+	}
+	if issuedAt := int64(bytOrder.Uint64(buffer[26:34])); issuedAt != 0 {
+		result.IssuedAt = time.Unix(issuedAt, 0)
+	}
+	copy(result.SessionId[:], buffer[34:50])
+	claimsCount := int(buffer[50])
+	if claimsCount > MaxClaims {
+		return 0, ErrorInvalidToken
+	}
+	offset := version2Size
+	if claimsCount > 0 {
+		result.Claims = make([]Claim, claimsCount)
+	}
+	for i := 0; i < claimsCount; i++ {
+		var ok bool
+		if result.Claims[i].Name, offset, ok = readClaimString(buffer, offset); !ok || result.Claims[i].Name == "" {
+			return 0, ErrorInvalidToken
+		}
+		if result.Claims[i].Value, offset, ok = readClaimString(buffer, offset); !ok {
+			return 0, ErrorInvalidToken
+		}
+	}
+	*token = result
+	return offset, nil
+}
+
+func readClaimString(buffer []byte, offset int) (string, int, bool) {
+	if offset >= len(buffer) {
+		return "", offset, false
+	}
+	end := offset + 1 + int(buffer[offset])
+	if end > len(buffer) {
+		return "", offset, false
+	}
+	return string(buffer[offset+1 : end]), end, true
+}
+
+// Claim returns the value of the custom claim
+func (token *Token) Claim(name string) (string, bool) {
+	for _, claim := range token.Claims {
+		if claim.Name == name {
+			return claim.Value, true
+		}
+	}
+	return "", false
+}
+
 func (token *Token) IsExpired() bool {
 	if !token.IsAuthenticated() {
 		return false
@@ -74,35 +214,133 @@ func (token *Token) IsExpired() bool {
 	return time.Now().After(token.ExpiresAt)
 }
 
-func (token *Token) WriteToString() string {
-	bytes := token.Write()
-	return base64.StdEncoding.EncodeToString(bytes[:])
+// Sign writes the token with the signer key id in the header followed by the payload signature
+func (token *Token) Sign(signer Signer) ([]byte, error) {
+	return token.signVersion(signer, tokenVersion)
+}
+
+func (token *Token) signVersion(signer Signer, version uint8) ([]byte, error) {
+	buffer, err := token.WriteVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	_, signature, err := signer.SignWithKey(func(keyId uint8) []byte {
+		buffer[1] = keyId
+		return buffer
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign token: %w", err)
+	}
+	return append(buffer, signature...), nil
+}
+
+// ReadSigned verifies the token signature and reads the token
+func (token *Token) ReadSigned(buffer []byte, verifier Verifier) error {
+	var result Token
+	size, err := result.read(buffer)
+	if err != nil {
+		return err
+	}
+	if size == len(buffer) {
+		return ErrorInvalidToken
+	}
+	if err = verifier.Verify(buffer[1], buffer[:size], buffer[size:]); err != nil {
+		return err
+	}
+	*token = result
+	return nil
+}
+
+// SetTokenVersion sets the format version of the written tokens. Keep writing the previous version until all
+// readers are upgraded
+func SetTokenVersion(version uint8) error {
+	if version != Version1 && version != Version2 {
+		return fmt.Errorf("unsupported token version %d", version)
+	}
+	tokenVersion = version
+	return nil
+}
+
+// SetSigner sets the signer used by WriteToString
+func SetSigner(signer Signer) {
+	defaultSigner = signer
+}
+
+// SetVerifier sets the verifier used by ReadFromString and WithStringToken
+func SetVerifier(verifier Verifier) {
+	defaultVerifier = verifier
+}
+
+func (token *Token) WriteToString() (string, error) {
+	return token.SignToString(defaultSigner)
+}
+
+// SignToString signs the token with the signer and encodes it as WriteToString does
+func (token *Token) SignToString(signer Signer) (string, error) {
+	if signer == nil {
+		return "", ErrorNoSigner
+	}
+	bytes, err := token.Sign(signer)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(bytes), nil
 }
 
 func ReadFromString(encoded string) (*Token, error) {
+	return VerifyString(encoded, defaultVerifier)
+}
+
+// VerifyString decodes the token written by WriteToString and verifies it with the verifier
+func VerifyString(encoded string, verifier Verifier) (*Token, error) {
+	if verifier == nil {
+		return nil, ErrorNoVerifier
+	}
 	buffer, err := base64.StdEncoding.DecodeString(encoded)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode string %w", err)
 	}
 	token := Token{}
-	return &token, token.Read(buffer)
+	return &token, token.ReadSigned(buffer, verifier)
 }
 
 func WithToken(ctx context.Context, tokenRef *Token) context.Context {
+	ctx = context.WithValue(ctx, &encodedTokenCtxKey{}, "")
 	return context.WithValue(ctx, &authTokenCtxKey{}, tokenRef)
 }
 
 func WithStringToken(ctx context.Context, token string) (context.Context, error) {
-	tokenRef, err := ReadFromString(token)
+	return WithVerifiedToken(ctx, token, defaultVerifier)
+}
+
+// WithVerifiedToken is WithStringToken with the token verified by the verifier
+func WithVerifiedToken(ctx context.Context, token string, verifier Verifier) (context.Context, error) {
+	tokenRef, err := VerifyString(token, verifier)
 	if err != nil {
 		return nil, err
 	}
 	if tokenRef.IsExpired() {
 		return nil, ErrorExpiredToken
 	}
+	if isRevoked(tokenRef) {
+		return nil, ErrorRevokedToken
+	}
+	if tokenRef.IsRefresh() {
+		// Refresh tokens are accepted only by Issuer.Refresh
+		return nil, ErrorInvalidToken
+	}
+	ctx = context.WithValue(ctx, &encodedTokenCtxKey{}, token)
 	return context.WithValue(ctx, &authTokenCtxKey{}, tokenRef), err
 }
 
+// EncodedToken returns the signed token exactly as it was received by WithStringToken
+func EncodedToken(ctx context.Context) string {
+	if token, ok := ctx.Value(&encodedTokenCtxKey{}).(string); ok {
+		return token
+	}
+	return ""
+}
+
 func AuthToken(ctx context.Context) Token {
 	if token, ok := ctx.Value(&authTokenCtxKey{}).(*Token); ok {
 		return *token
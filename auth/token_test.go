@@ -1,6 +1,12 @@
 package auth
 
 import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/google/uuid"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -12,14 +18,17 @@ func TestTokenReadWrite(t *testing.T) {
 		Role:      Operator,
 		ExpiresAt: time.Now().Round(time.Second),
 	}
-	binaryToken := token.Write()
+	binaryToken, err := token.Write()
+	if err != nil {
+		t.Fatalf("Unexpecte error %v", err)
+	}
 
 	var tokenCopy Token
-	if err := tokenCopy.Read(binaryToken[:]); err != nil {
+	if err := tokenCopy.Read(binaryToken); err != nil {
 		t.Errorf("Unexpecte error %v", err)
 	}
 
-	if token != tokenCopy {
+	if !reflect.DeepEqual(token, tokenCopy) {
 		t.Errorf("Tokens should match, token: %v, copy: %v", token, tokenCopy)
 	}
 }
@@ -87,20 +96,293 @@ func TestTokenReadWriteString(t *testing.T) {
 		ExpiresAt: time.Unix(1686841129, 0),
 	}
 
-	tokenString := token.WriteToString()
+	keyring := newTestKeyring(t)
+	SetSigner(keyring)
+	SetVerifier(keyring)
+	defer SetSigner(nil)
+	defer SetVerifier(nil)
+
+	tokenString, err := token.WriteToString()
+	if err != nil {
+		t.Fatalf("Unexpecte error %v", err)
+	}
 
 	tokenCopy, err := ReadFromString(tokenString)
 	if err != nil {
 		t.Errorf("Unexpecte error %v", err)
 	}
-	if token != *tokenCopy {
+	if !reflect.DeepEqual(token, *tokenCopy) {
 		t.Errorf("Tokens should match, token: %v, copy: %v", token, tokenCopy)
 	}
 }
 
 func TestTokenReadInvalidString(t *testing.T) {
+	keyring := newTestKeyring(t)
+	SetVerifier(keyring)
+	defer SetVerifier(nil)
+
 	_, err := ReadFromString("BDAAAAAAAAAABAAAAAwABgAAAAAAAGSLJyk=")
 	if err != ErrorInvalidToken {
 		t.Errorf("Expecting ErrorInvalidToken, got %v", err)
 	}
 }
+
+func TestTokenReadUnsignedString(t *testing.T) {
+	keyring := newTestKeyring(t)
+	SetVerifier(keyring)
+	defer SetVerifier(nil)
+
+	// Unsigned token produced by the previous versions
+	_, err := ReadFromString("AQAAAAAAAAAABAAAAAwABgAAAAAAAGSLJyk=")
+	if err != ErrorInvalidToken {
+		t.Errorf("Expecting ErrorInvalidToken, got %v", err)
+	}
+}
+
+func TestTokenReadStringNoVerifier(t *testing.T) {
+	_, err := ReadFromString("AQAAAAAAAAAABAAAAAwABgAAAAAAAGSLJyk=")
+	if err != ErrorNoVerifier {
+		t.Errorf("Expecting ErrorNoVerifier, got %v", err)
+	}
+}
+
+func TestTokenWriteStringNoSigner(t *testing.T) {
+	token := Token{
+		AccountId: 4,
+		Role:      Operator,
+	}
+	_, err := token.WriteToString()
+	if err != ErrorNoSigner {
+		t.Errorf("Expecting ErrorNoSigner, got %v", err)
+	}
+}
+
+func TestWithStringTokenInvalidSignature(t *testing.T) {
+	keyring := newTestKeyring(t)
+	SetSigner(keyring)
+	SetVerifier(keyring)
+	defer SetSigner(nil)
+	defer SetVerifier(nil)
+
+	token := Token{
+		AccountId: 4,
+		GroupId:   12,
+		Role:      User,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	encoded, err := token.WriteToString()
+	if err != nil {
+		t.Fatalf("Unexpecte error %v", err)
+	}
+	buffer, _ := base64.StdEncoding.DecodeString(encoded)
+	// Escalate the role
+	buffer[15] = byte(Admin)
+
+	_, err = WithStringToken(context.Background(), base64.StdEncoding.EncodeToString(buffer))
+	if err != ErrorInvalidSignature {
+		t.Errorf("Expecting ErrorInvalidSignature, got %v", err)
+	}
+}
+
+func TestWithStringToken(t *testing.T) {
+	keyring := newTestKeyring(t)
+	SetSigner(keyring)
+	SetVerifier(keyring)
+	defer SetSigner(nil)
+	defer SetVerifier(nil)
+
+	token := Token{
+		AccountId: 4,
+		GroupId:   12,
+		Role:      User,
+		ExpiresAt: time.Now().Add(time.Hour).Round(time.Second),
+	}
+	encoded, err := token.WriteToString()
+	if err != nil {
+		t.Fatalf("Unexpecte error %v", err)
+	}
+
+	ctx, err := WithStringToken(context.Background(), encoded)
+	if err != nil {
+		t.Fatalf("Unexpecte error %v", err)
+	}
+	if !reflect.DeepEqual(AuthToken(ctx), token) {
+		t.Errorf("Tokens should match, token: %v, copy: %v", token, AuthToken(ctx))
+	}
+	if EncodedToken(ctx) != encoded {
+		t.Errorf("Encoded token should match, expected: %s, actual: %s", encoded, EncodedToken(ctx))
+	}
+}
+
+func newTestTokenV2() Token {
+	return Token{
+		AccountId: 4,
+		GroupId:   12,
+		Role:      Manager,
+		ExpiresAt: time.Unix(1686841129, 0),
+		IssuedAt:  time.Unix(1686837529, 0),
+		SessionId: uuid.MustParse("7d444840-9dc0-11d1-b245-5ffdce74fad2"),
+		Claims: []Claim{
+			{Name: "tenant", Value: "acme"},
+			{Name: "scope", Value: ""},
+		},
+	}
+}
+
+func TestTokenReadWriteV2(t *testing.T) {
+	token := newTestTokenV2()
+	buffer, err := token.WriteVersion(Version2)
+	if err != nil {
+		t.Fatalf("Unexpecte error %v", err)
+	}
+
+	var tokenCopy Token
+	if err = tokenCopy.Read(buffer); err != nil {
+		t.Errorf("Unexpecte error %v", err)
+	}
+	if !reflect.DeepEqual(token, tokenCopy) {
+		t.Errorf("Tokens should match, token: %v, copy: %v", token, tokenCopy)
+	}
+	if tenant, _ := tokenCopy.Claim("tenant"); tenant != "acme" {
+		t.Errorf("Expecting tenant claim acme, got %s", tenant)
+	}
+	if _, ok := tokenCopy.Claim("missing"); ok {
+		t.Errorf("Claim must not be found")
+	}
+}
+
+func TestTokenReadWriteV1(t *testing.T) {
+	token := newTestTokenV2()
+	buffer, err := token.WriteVersion(Version1)
+	if err != nil {
+		t.Fatalf("Unexpecte error %v", err)
+	}
+	if len(buffer) != 26 {
+		t.Errorf("Version 1 token must be 26 bytes, got %d", len(buffer))
+	}
+
+	var tokenCopy Token
+	if err = tokenCopy.Read(buffer); err != nil {
+		t.Errorf("Unexpecte error %v", err)
+	}
+	expected := Token{
+		AccountId: token.AccountId,
+		GroupId:   token.GroupId,
+		Role:      token.Role,
+		ExpiresAt: token.ExpiresAt,
+	}
+	if !reflect.DeepEqual(expected, tokenCopy) {
+		t.Errorf("Tokens should match, token: %v, copy: %v", expected, tokenCopy)
+	}
+}
+
+func TestTokenSignedVersions(t *testing.T) {
+	keyring := newTestKeyring(t)
+	defer func() {
+		_ = SetTokenVersion(Version2)
+	}()
+
+	for _, version := range []uint8{Version1, Version2} {
+		if err := SetTokenVersion(version); err != nil {
+			t.Fatalf("Unexpecte error %v", err)
+		}
+		token := newTestTokenV2()
+		buffer, err := token.Sign(keyring)
+		if err != nil {
+			t.Fatalf("Unexpecte error %v", err)
+		}
+		if buffer[0] != version {
+			t.Errorf("Expecting version %d, got %d", version, buffer[0])
+		}
+		var tokenCopy Token
+		if err = tokenCopy.ReadSigned(buffer, keyring); err != nil {
+			t.Errorf("Version %d: unexpecte error %v", version, err)
+		}
+		if tokenCopy.AccountId != token.AccountId {
+			t.Errorf("Version %d: tokens should match, token: %v, copy: %v", version, token, tokenCopy)
+		}
+	}
+}
+
+func TestTokenWriteInvalidClaims(t *testing.T) {
+	token := newTestTokenV2()
+	token.Claims = make([]Claim, MaxClaims+1)
+	for i := range token.Claims {
+		token.Claims[i].Name = fmt.Sprintf("claim%d", i)
+	}
+	if _, err := token.WriteVersion(Version2); err == nil {
+		t.Errorf("Too many claims must be rejected")
+	}
+
+	token.Claims = []Claim{{Name: "", Value: "value"}}
+	if _, err := token.WriteVersion(Version2); err == nil {
+		t.Errorf("Empty claim name must be rejected")
+	}
+
+	token.Claims = []Claim{{Name: "name", Value: strings.Repeat("a", 256)}}
+	if _, err := token.WriteVersion(Version2); err == nil {
+		t.Errorf("Long claim value must be rejected")
+	}
+}
+
+func TestTokenReadTruncatedV2(t *testing.T) {
+	token := newTestTokenV2()
+	buffer, err := token.WriteVersion(Version2)
+	if err != nil {
+		t.Fatalf("Unexpecte error %v", err)
+	}
+	for i := 0; i < len(buffer); i++ {
+		var tokenCopy Token
+		if err = tokenCopy.Read(buffer[:i]); err != ErrorInvalidToken {
+			t.Errorf("Size %d: expecting ErrorInvalidToken, got %v", i, err)
+		}
+	}
+}
+
+func FuzzTokenRead(f *testing.F) {
+	v2 := newTestTokenV2()
+	v2Buffer, _ := v2.WriteVersion(Version2)
+	v1Buffer, _ := v2.WriteVersion(Version1)
+	f.Add(v1Buffer)
+	f.Add(v2Buffer)
+	f.Add([]byte{})
+	f.Add([]byte{2, 0})
+
+	f.Fuzz(func(t *testing.T, buffer []byte) {
+		var token Token
+		if err := token.Read(buffer); err != nil {
+			return
+		}
+		copyBuffer, err := token.WriteVersion(buffer[0])
+		if err != nil {
+			t.Fatalf("Decoded token must be writable, got %v", err)
+		}
+		var tokenCopy Token
+		if err = tokenCopy.Read(copyBuffer); err != nil {
+			t.Fatalf("Written token must be readable, got %v", err)
+		}
+		if !reflect.DeepEqual(token, tokenCopy) {
+			t.Errorf("Tokens should match, token: %v, copy: %v", token, tokenCopy)
+		}
+	})
+}
+
+func FuzzTokenReadSigned(f *testing.F) {
+	keyring := NewHmacKeyring()
+	_ = keyring.AddKey(1, make([]byte, 32))
+	_ = keyring.Rotate(1)
+	v2 := newTestTokenV2()
+	signed, _ := v2.Sign(keyring)
+	f.Add(signed)
+	f.Add(signed[:51])
+
+	f.Fuzz(func(t *testing.T, buffer []byte) {
+		var token Token
+		if err := token.ReadSigned(buffer, keyring); err != nil {
+			return
+		}
+		if buffer[1] != 1 {
+			t.Errorf("Token with unknown key %d accepted", buffer[1])
+		}
+	})
+}
@@ -1,18 +1,26 @@
 package config
 
 import (
+	"encoding"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
-	"unicode"
+	"time"
 )
 
+var ErrorUnsupportedType = errors.New("unsupported type")
+var ErrorInvalidKey = errors.New("invalid key")
+
+// Password hides the value from the logs and the marshalled configuration. A value with a registered scheme, like
+// file:/run/secrets/db or env:DB_PASSWORD, is a secret reference, resolved on Read and refreshed by RefreshSecrets. The
+// literal: prefix escapes a password which starts with a scheme, literal:file:abc is the file:abc password
 type Password struct {
-	value *string
+	value  *string
+	secret *secret
 }
 
 func NewPassword(value string) Password {
@@ -22,6 +30,9 @@ func NewPassword(value string) Password {
 }
 
 func (p Password) Value() string {
+	if p.secret != nil {
+		return p.secret.get()
+	}
 	if p.value == nil {
 		return ""
 	}
@@ -32,6 +43,24 @@ func (p Password) Format(f fmt.State, _ rune) {
 	_, _ = f.Write([]byte{'*', '*', '*'})
 }
 
+// MarshalJSON never reveals the value, the marshalled configuration is safe to expose
+func (p Password) MarshalJSON() ([]byte, error) {
+	return []byte(`"***"`), nil
+}
+
+func (p *Password) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	password, err := ParsePassword(value)
+	if err != nil {
+		return err
+	}
+	*p = password
+	return nil
+}
+
 type DbConfig struct {
 	Host     string
 	Port     uint16
@@ -40,75 +69,40 @@ type DbConfig struct {
 	DbName   string
 }
 
+// Read reads the configuration into val, a pointer to a struct. The sources are applied in order: default tags,
+// the JSON, YAML or TOML files from the -f flags, the environment variables, the key=value command line arguments.
+// Fields tagged required:"true" must be set by one of them. The config tag renames the field in the keys and the
+// variable names, config:"-" skips it, the env tag is the exact variable name. Password values like
+// file:/run/secrets/db are resolved by the SecretProvider of the scheme
 func Read(val any) error {
+	return ReadWithEnvPrefix(val, "")
+}
+
+// ReadWithEnvPrefix see Read, the environment variable names start with the prefix, APP gives APP_DB_HOST for the
+// Host field of the Db section
+func ReadWithEnvPrefix(val any, prefix string) error {
+	var nilSections []reflect.Value
+	if err := applyDefaults(reflect.ValueOf(val), &nilSections); err != nil {
+		return fmt.Errorf("cfg error: %w", err)
+	}
 	if err := fromFile(val); err != nil {
 		return err
 	}
-	if err := fromEnv(val); err != nil {
+	if err := fromEnvPrefix(val, prefix); err != nil {
 		return err
 	}
-	return fromCommandLine(val)
-}
-
-func fromFile(val any) error {
-	fileName := getFlag("-f", os.Args)
-	if fileName != "" {
-		absFilePath, err := filepath.Abs(fileName)
-		if err != nil {
-			return fmt.Errorf("can not get absolute file path for file %s : %w", fileName, err)
-		}
-		fmt.Printf("Reading configuration from file %s\n", absFilePath)
-		file, err := os.Open(fileName)
-		if err != nil {
-			return fmt.Errorf("can not open config file: %w", err)
-		}
-		defer func(file *os.File) {
-			err := file.Close()
-			if err != nil {
-				fmt.Printf("unexpected error while closing config file %v", err)
-			}
-		}(file)
-		decoder := json.NewDecoder(file)
-		return decoder.Decode(val)
+	if err := fromCommandLine(val); err != nil {
+		return err
 	}
-	return nil
-}
-
-func fromEnv(val any) error {
-	cfgMap := make(map[string]string, 0)
-	for _, a := range os.Environ() {
-		idx := strings.IndexRune(a, '=')
-		if idx != -1 && idx != len(a)-1 {
-			key := normalizeEnvKey(a[:idx])
-			value := a[idx+1:]
-			cfgMap[key] = value
-		}
+	if err := applySectionDefaults(nilSections); err != nil {
+		return fmt.Errorf("cfg error: %w", err)
 	}
-	if len(cfgMap) > 0 {
-		fmt.Println("Reading configuration from environment variables")
-		return updateConfig(val, &cfgMap)
+	if missing := checkRequired(reflect.ValueOf(val), "", prefix, nil); len(missing) > 0 {
+		return &RequiredError{Fields: missing}
 	}
 	return nil
 }
 
-func normalizeEnvKey(key string) string {
-	keyRunes := []rune(key)
-	mustBeUpper := true
-	for i, r := range keyRunes {
-		if r == '_' {
-			keyRunes[i] = '.'
-			mustBeUpper = true
-		} else if mustBeUpper {
-			keyRunes[i] = unicode.ToUpper(r)
-			mustBeUpper = false
-		} else {
-			keyRunes[i] = unicode.ToLower(r)
-		}
-
-	}
-	return string(keyRunes)
-}
-
 func fromCommandLine(val any) error {
 	cfgMap := make(map[string]string, 0)
 	for _, a := range os.Args {
@@ -142,59 +136,221 @@ func updateConfigField(conf reflect.Value, key, value string) error {
 		fieldName = key[:idx]
 		subpath = key[idx+1:]
 	}
-	if conf.Kind() == reflect.Pointer {
-		conf = conf.Elem()
+	conf = indirect(conf)
+	if conf.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %s has no fields", ErrorUnsupportedType, conf.Type())
 	}
-	field := conf.FieldByName(fieldName)
-	if !field.IsValid() {
+	field := findField(conf, fieldName)
+	if !field.IsValid() || !field.CanSet() {
 		// Subfield does not exist
 		return nil
 	}
 	if subpath == "" {
-		// Set the value of the field
-		switch field.Kind() {
-		case reflect.String:
-			field.SetString(value)
-		case reflect.Bool:
-			boolVal, err := strconv.ParseBool(value)
-			if err == nil {
-				field.SetBool(boolVal)
-			} else {
-				return err
+		return setValue(field, value)
+	}
+	return updateElement(field, subpath, value)
+}
+
+// updateElement sets the element addressed by the path: a field of a struct, an index of a slice or an array, a key
+// of a map
+func updateElement(field reflect.Value, path, value string) error {
+	if isValueType(field.Type()) {
+		return fmt.Errorf("%w: %s has no elements", ErrorUnsupportedType, field.Type())
+	}
+	field = indirect(field)
+	var elementKey = path
+	var subpath string
+	if idx := strings.IndexRune(path, '.'); idx != -1 {
+		elementKey = path[:idx]
+		subpath = path[idx+1:]
+	}
+	update := func(element reflect.Value) error {
+		if subpath == "" {
+			return setValue(element, value)
+		}
+		return updateElement(element, subpath, value)
+	}
+	switch field.Kind() {
+	case reflect.Struct:
+		return updateConfigField(field, path, value)
+	case reflect.Slice, reflect.Array:
+		idx, err := strconv.Atoi(elementKey)
+		if err != nil || idx < 0 {
+			return fmt.Errorf("invalid index [%s]: %w", elementKey, ErrorInvalidKey)
+		}
+		if idx >= field.Len() {
+			if field.Kind() == reflect.Array {
+				return fmt.Errorf("index %d out of range %d: %w", idx, field.Len(), ErrorInvalidKey)
+			}
+			grown := reflect.MakeSlice(field.Type(), idx+1, idx+1)
+			reflect.Copy(grown, field)
+			field.Set(grown)
+		}
+		return update(field.Index(idx))
+	case reflect.Map:
+		mapKey := reflect.New(field.Type().Key()).Elem()
+		if err := setValue(mapKey, elementKey); err != nil {
+			return fmt.Errorf("invalid key [%s]: %w", elementKey, err)
+		}
+		// map elements are not addressable, update a copy
+		element := reflect.New(field.Type().Elem()).Elem()
+		if field.IsNil() {
+			field.Set(reflect.MakeMap(field.Type()))
+		} else if existing := field.MapIndex(mapKey); existing.IsValid() {
+			element.Set(existing)
+		}
+		if err := update(element); err != nil {
+			return err
+		}
+		field.SetMapIndex(mapKey, element)
+		return nil
+	default:
+		return fmt.Errorf("%w: %s has no elements", ErrorUnsupportedType, field.Type())
+	}
+}
+
+// indirect follows the pointers allocating the nil ones
+func indirect(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+var passwordType = reflect.TypeOf(Password{})
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// isValueType types set from a single value as a whole
+func isValueType(t reflect.Type) bool {
+	return t == passwordType || t == durationType || reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// setValue parses the value into the field. Slices are comma separated lists, maps are comma separated key=value
+// pairs, both replace the current content
+func setValue(field reflect.Value, value string) error {
+	if field.Type() == passwordType {
+		password, err := ParsePassword(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(password))
+		return nil
+	}
+	if field.Type() == durationType {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+		return nil
+	}
+	if field.CanAddr() && field.Addr().Type().Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+	if field.CanAddr() && field.Addr().Type().Implements(jsonUnmarshalerType) {
+		// the value is given as a JSON string, then as is, so codes.Code is set from UNAVAILABLE or 14
+		unmarshaler := field.Addr().Interface().(json.Unmarshaler)
+		quoted, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if err = unmarshaler.UnmarshalJSON(quoted); err != nil && json.Valid([]byte(value)) {
+			return unmarshaler.UnmarshalJSON([]byte(value))
+		}
+		return err
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		boolVal, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(boolVal)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		intVal, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(intVal)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		intVal, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(intVal)
+	case reflect.Float32, reflect.Float64:
+		floatVal, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(floatVal)
+	case reflect.Pointer:
+		element := reflect.New(field.Type().Elem())
+		if err := setValue(element.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(element)
+	case reflect.Slice:
+		items := splitList(value)
+		slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(slice.Index(i), item); err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
 			}
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			intVal, err := strconv.ParseInt(value, 10, 64)
-			if err == nil {
-				field.SetInt(intVal)
-			} else {
-				return err
+		}
+		field.Set(slice)
+	case reflect.Map:
+		items := splitList(value)
+		result := reflect.MakeMapWithSize(field.Type(), len(items))
+		for _, item := range items {
+			idx := strings.IndexRune(item, '=')
+			if idx == -1 {
+				return fmt.Errorf("map item [%s] is not key=value: %w", item, ErrorInvalidKey)
 			}
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			intVal, err := strconv.ParseUint(value, 10, 64)
-			if err == nil {
-				field.SetUint(intVal)
-			} else {
-				return err
+			mapKey := reflect.New(field.Type().Key()).Elem()
+			if err := setValue(mapKey, strings.TrimSpace(item[:idx])); err != nil {
+				return fmt.Errorf("map key [%s]: %w", item[:idx], err)
 			}
-		case reflect.Float32, reflect.Float64:
-			floatVal, err := strconv.ParseFloat(value, 64)
-			if err == nil {
-				field.SetFloat(floatVal)
-			} else {
-				return err
+			element := reflect.New(field.Type().Elem()).Elem()
+			if err := setValue(element, strings.TrimSpace(item[idx+1:])); err != nil {
+				return fmt.Errorf("map item [%s]: %w", item, err)
 			}
+			result.SetMapIndex(mapKey, element)
 		}
+		field.Set(result)
+	default:
+		return fmt.Errorf("%w: %s", ErrorUnsupportedType, field.Type())
 	}
+	return nil
+}
 
-	if field.Type() == reflect.TypeOf(Password{}) {
-		field.Set(reflect.ValueOf(NewPassword(value)))
-	} else if field.Kind() == reflect.Struct {
-		if err := updateConfigField(field, subpath, value); err != nil {
-			return err
+func splitList(value string) []string {
+	items := strings.Split(value, ",")
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
 		}
 	}
+	return result
+}
 
-	return nil
+// getFlags returns the values of all the occurrences of the flag
+func getFlags(flag string, args []string) []string {
+	var result []string
+	for _, a := range args {
+		if strings.HasPrefix(a, flag) && len(a) > len(flag) {
+			result = append(result, a[len(flag):])
+		}
+	}
+	return result
 }
 
 func getFlag(flag string, args []string) string {
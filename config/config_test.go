@@ -3,8 +3,11 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
+	"reflect"
 	"strconv"
 	"testing"
+	"time"
 )
 
 type level3Config struct {
@@ -219,46 +222,20 @@ func TestUpdateVal(t *testing.T) {
 	}
 }
 
-func TestNormalizeToUpper(t *testing.T) {
-	type spec struct {
-		input    string
-		expected string
-	}
-
-	suite := []spec{
-		{
-			input:    "abc",
-			expected: "Abc",
-		},
-		{
-			input:    "ABC",
-			expected: "Abc",
-		},
-		{
-			input:    "прИвет",
-			expected: "Привет",
-		},
-		{
-			input:    "SNAKE_FOO_BAR",
-			expected: "Snake.Foo.Bar",
-		},
-		{
-			input:    "...",
-			expected: "...",
-		},
-		{
-			input:    "___a",
-			expected: "...A",
-		},
+func TestEnvName(t *testing.T) {
+	suite := map[string]string{
+		"Host":       "HOST",
+		"DbName":     "DB_NAME",
+		"KnownPeers": "KNOWN_PEERS",
+		"HTTPPort":   "HTTP_PORT",
+		"TLS":        "TLS",
+		"Ipv4Addr":   "IPV4_ADDR",
+		"cache-ttl":  "CACHE_TTL",
 	}
-
-	for i, test := range suite {
-		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
-			output := normalizeEnvKey(test.input)
-			if output != test.expected {
-				t.Errorf("expected [%s], actual [%s]", test.expected, output)
-			}
-		})
+	for input, expected := range suite {
+		if output := envName(input); output != expected {
+			t.Errorf("%s: expected [%s], actual [%s]", input, expected, output)
+		}
 	}
 }
 
@@ -310,3 +287,123 @@ func TestGetFlag(t *testing.T) {
 	}
 
 }
+
+type peerConfig struct {
+	Name    string
+	Timeout time.Duration
+}
+
+type typesConfig struct {
+	KnownPeers []string
+	Ports      []uint16
+	Labels     map[string]string
+	Limits     map[string]int
+	Timeout    time.Duration
+	Deadline   time.Time
+	Address    net.IP
+	Peer       *peerConfig
+	Peers      []peerConfig
+	PeerMap    map[string]peerConfig
+	Retries    *int
+	Small      int8
+	Handler    func()
+	Fixed      [2]string
+}
+
+func TestUpdateTypes(t *testing.T) {
+	retries := 3
+	type spec struct {
+		name        string
+		params      map[string]string
+		expected    typesConfig
+		expectedErr error
+	}
+	suite := []spec{
+		{
+			name:     "CommaList",
+			params:   map[string]string{"KnownPeers": "svc-a, svc-b", "Ports": "80,443"},
+			expected: typesConfig{KnownPeers: []string{"svc-a", "svc-b"}, Ports: []uint16{80, 443}},
+		},
+		{
+			name:     "IndexedList",
+			params:   map[string]string{"KnownPeers.1": "svc-b", "KnownPeers.0": "svc-a"},
+			expected: typesConfig{KnownPeers: []string{"svc-a", "svc-b"}},
+		},
+		{
+			name:     "Map",
+			params:   map[string]string{"Labels": "env=prod,team=core", "Limits.read": "10"},
+			expected: typesConfig{Labels: map[string]string{"env": "prod", "team": "core"}, Limits: map[string]int{"read": 10}},
+		},
+		{
+			name:     "Duration",
+			params:   map[string]string{"Timeout": "1m30s"},
+			expected: typesConfig{Timeout: 90 * time.Second},
+		},
+		{
+			name:     "TextUnmarshaler",
+			params:   map[string]string{"Deadline": "2024-01-02T03:04:05Z", "Address": "10.0.0.1"},
+			expected: typesConfig{Deadline: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Address: net.ParseIP("10.0.0.1")},
+		},
+		{
+			name:     "Pointers",
+			params:   map[string]string{"Peer.Name": "svc-a", "Peer.Timeout": "1s", "Retries": "3"},
+			expected: typesConfig{Peer: &peerConfig{Name: "svc-a", Timeout: time.Second}, Retries: &retries},
+		},
+		{
+			name:     "NestedElements",
+			params:   map[string]string{"Peers.0.Name": "svc-a", "PeerMap.b.Timeout": "2s", "Fixed.1": "x"},
+			expected: typesConfig{Peers: []peerConfig{{Name: "svc-a"}}, PeerMap: map[string]peerConfig{"b": {Timeout: 2 * time.Second}}, Fixed: [2]string{"", "x"}},
+		},
+		{
+			name:        "Unsupported",
+			params:      map[string]string{"Handler": "x"},
+			expectedErr: ErrorUnsupportedType,
+		},
+		{
+			name:        "StructValue",
+			params:      map[string]string{"Peer": "x"},
+			expectedErr: ErrorUnsupportedType,
+		},
+		{
+			name:        "InvalidIndex",
+			params:      map[string]string{"KnownPeers.x": "svc-a"},
+			expectedErr: ErrorInvalidKey,
+		},
+		{
+			name:        "ArrayOutOfRange",
+			params:      map[string]string{"Fixed.2": "x"},
+			expectedErr: ErrorInvalidKey,
+		},
+		{
+			name:        "Overflow",
+			params:      map[string]string{"Small": "300"},
+			expectedErr: strconv.ErrRange,
+		},
+		{
+			name:        "InvalidDuration",
+			params:      map[string]string{"Timeout": "soon"},
+			expectedErr: errors.New(""),
+		},
+	}
+	for _, test := range suite {
+		t.Run(test.name, func(t *testing.T) {
+			value := typesConfig{}
+			err := updateConfig(&value, &test.params)
+			if test.expectedErr != nil {
+				if err == nil {
+					t.Fatalf("Expecting error %v", test.expectedErr)
+				}
+				if test.expectedErr.Error() != "" && !errors.Is(err, test.expectedErr) {
+					t.Errorf("Expecting error %v, got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(test.expected, value) {
+				t.Errorf("Expecting %+v, got %+v", test.expected, value)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EncryptedFileProvider resolves encrypted:/etc/app/secrets.enc#db references. The file is a JSON object of the
+// secrets sealed by EncryptSecrets with AES-256-GCM, the nonce is the file prefix
+type EncryptedFileProvider struct {
+	aead cipher.AEAD
+}
+
+// NewEncryptedFileProvider creates the provider with a 32 bytes key, register it with RegisterSecretProvider
+func NewEncryptedFileProvider(key []byte) (*EncryptedFileProvider, error) {
+	aead, err := newAead(key)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedFileProvider{aead: aead}, nil
+}
+
+func newAead(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid key size %d, expecting 32 bytes", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (p *EncryptedFileProvider) Scheme() string {
+	return "encrypted"
+}
+
+// Secret the file is read on every call, so a replaced file is picked up by RefreshSecrets
+func (p *EncryptedFileProvider) Secret(ref string) (string, error) {
+	idx := strings.LastIndexByte(ref, '#')
+	if idx == -1 {
+		return "", fmt.Errorf("invalid reference [%s], expecting file#name", ref)
+	}
+	fileName, name := ref[:idx], ref[idx+1:]
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return "", err
+	}
+	nonceSize := p.aead.NonceSize()
+	if len(data) < nonceSize {
+		return "", fmt.Errorf("invalid secrets file %s", fileName)
+	}
+	plain, err := p.aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return "", fmt.Errorf("can not decrypt secrets file %s: %w", fileName, err)
+	}
+	secrets := make(map[string]string)
+	if err = json.Unmarshal(plain, &secrets); err != nil {
+		return "", fmt.Errorf("invalid secrets file %s: %w", fileName, err)
+	}
+	value, ok := secrets[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s in %s", ErrorSecretNotFound, name, fileName)
+	}
+	return value, nil
+}
+
+// EncryptSecrets seals the secrets into the EncryptedFileProvider file format
+func EncryptSecrets(key []byte, secrets map[string]string) ([]byte, error) {
+	aead, err := newAead(key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plain, nil), nil
+}
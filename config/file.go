@@ -0,0 +1,226 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const formatFlag = "-format="
+
+const (
+	FormatJson = "json"
+	FormatYaml = "yaml"
+	FormatToml = "toml"
+)
+
+// fromFile reads the files from the -f flags in order, the later files override the earlier ones: fields present in
+// a file are set, maps are merged, lists are replaced. The format is taken from the -format= flag or the extension
+func fromFile(val any) error {
+	format := getFlag(formatFlag, os.Args)
+	for _, fileName := range configFiles() {
+		absFilePath, err := filepath.Abs(fileName)
+		if err != nil {
+			return fmt.Errorf("can not get absolute file path for file %s : %w", fileName, err)
+		}
+		fmt.Printf("Reading configuration from file %s\n", absFilePath)
+		fileFormat := format
+		if fileFormat == "" {
+			fileFormat = formatOf(fileName)
+		}
+		if err = readFile(val, fileName, fileFormat); err != nil {
+			return fmt.Errorf("config file %s: %w", fileName, err)
+		}
+	}
+	return nil
+}
+
+// configFiles the -f flags values in order
+func configFiles() []string {
+	var result []string
+	for _, fileName := range getFlags("-f", os.Args) {
+		if !strings.HasPrefix("-f"+fileName, formatFlag) {
+			result = append(result, fileName)
+		}
+	}
+	return result
+}
+
+func formatOf(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".yaml", ".yml":
+		return FormatYaml
+	case ".toml":
+		return FormatToml
+	default:
+		return FormatJson
+	}
+}
+
+func readFile(val any, fileName string, format string) error {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("can not read config file: %w", err)
+	}
+	// the files are bound like the environment variables and the arguments: the config tags, case and underscore
+	// insensitive names, Password and Duration from strings. The json tags, json.Unmarshaler and Duration from
+	// nanoseconds work as with encoding/json
+	tree := make(map[string]any)
+	switch format {
+	case FormatJson:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&tree)
+	case FormatYaml:
+		err = yaml.Unmarshal(data, &tree)
+	case FormatToml:
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return fmt.Errorf("unknown config format [%s]", format)
+	}
+	if err != nil {
+		return err
+	}
+	return applyTree(reflect.ValueOf(val), tree)
+}
+
+// applyTree sets the fields from a decoded document, unknown keys are ignored
+func applyTree(conf reflect.Value, tree map[string]any) error {
+	conf = indirect(conf)
+	if conf.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %s has no fields", ErrorUnsupportedType, conf.Type())
+	}
+	for key, value := range tree {
+		field := findFileField(conf, key)
+		if !field.IsValid() || !field.CanSet() {
+			continue
+		}
+		if err := assignValue(field, value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// findFileField finds the field by the json tag name, then like findField. Fields tagged json:"-" are not read from
+// the files
+func findFileField(conf reflect.Value, name string) reflect.Value {
+	t := conf.Type()
+	for i := 0; i < t.NumField(); i++ {
+		configName, ok := fieldName(t.Field(i))
+		if !ok {
+			continue
+		}
+		jsonName, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		switch {
+		case jsonName == "-" && (sameName(configName, name) || sameName(t.Field(i).Name, name)):
+			return reflect.Value{}
+		case jsonName != "" && jsonName != "-" && strings.EqualFold(jsonName, name):
+			return conf.Field(i)
+		}
+	}
+	return findField(conf, name)
+}
+
+func assignValue(field reflect.Value, value any) error {
+	if value == nil {
+		return nil
+	}
+	if timeValue, ok := value.(time.Time); ok && field.Type() == reflect.TypeOf(timeValue) {
+		field.Set(reflect.ValueOf(timeValue))
+		return nil
+	}
+	if _, ok := value.(string); !ok && field.Type() == durationType {
+		// numbers are nanoseconds
+		nanoseconds, err := strconv.ParseInt(scalarString(value), 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(nanoseconds)
+		return nil
+	}
+	if field.Type() != passwordType && reflect.PointerTo(field.Type()).Implements(jsonUnmarshalerType) {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		return field.Addr().Interface().(json.Unmarshaler).UnmarshalJSON(data)
+	}
+	node := reflect.ValueOf(value)
+	isList := node.Kind() == reflect.Slice
+	isTree := node.Kind() == reflect.Map
+	if !isList && !isTree {
+		return setValue(field, scalarString(value))
+	}
+	if isValueType(field.Type()) {
+		return fmt.Errorf("%w: %s from a list or a table", ErrorUnsupportedType, field.Type())
+	}
+	field = indirect(field)
+	switch {
+	case isTree && field.Kind() == reflect.Struct:
+		tree := make(map[string]any, node.Len())
+		iterator := node.MapRange()
+		for iterator.Next() {
+			tree[fmt.Sprint(iterator.Key().Interface())] = iterator.Value().Interface()
+		}
+		return applyTree(field, tree)
+	case isTree && field.Kind() == reflect.Map:
+		if field.IsNil() {
+			field.Set(reflect.MakeMap(field.Type()))
+		}
+		iterator := node.MapRange()
+		for iterator.Next() {
+			mapKey := reflect.New(field.Type().Key()).Elem()
+			if err := setValue(mapKey, fmt.Sprint(iterator.Key().Interface())); err != nil {
+				return fmt.Errorf("invalid key [%v]: %w", iterator.Key().Interface(), err)
+			}
+			element := reflect.New(field.Type().Elem()).Elem()
+			if existing := field.MapIndex(mapKey); existing.IsValid() {
+				element.Set(existing)
+			}
+			if err := assignValue(element, iterator.Value().Interface()); err != nil {
+				return fmt.Errorf("%v: %w", iterator.Key().Interface(), err)
+			}
+			field.SetMapIndex(mapKey, element)
+		}
+		return nil
+	case isList && (field.Kind() == reflect.Slice || field.Kind() == reflect.Array):
+		if field.Kind() == reflect.Array && node.Len() > field.Len() {
+			return fmt.Errorf("%d items do not fit %s: %w", node.Len(), field.Type(), ErrorInvalidKey)
+		}
+		target := field
+		if field.Kind() == reflect.Slice {
+			target = reflect.MakeSlice(field.Type(), node.Len(), node.Len())
+		}
+		for i := 0; i < node.Len(); i++ {
+			if err := assignValue(target.Index(i), node.Index(i).Interface()); err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+		field.Set(target)
+		return nil
+	default:
+		return fmt.Errorf("%w: %s from a list or a table", ErrorUnsupportedType, field.Type())
+	}
+}
+
+func scalarString(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case time.Time:
+		return typed.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fileDbConfig struct {
+	Host     string
+	Port     uint16
+	Password Password
+}
+
+type fileConfig struct {
+	Name       string `config:"service_name"`
+	Timeout    time.Duration
+	KnownPeers []string
+	Labels     map[string]string
+	Db         fileDbConfig
+	Replicas   []fileDbConfig
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	return path
+}
+
+func TestReadFiles(t *testing.T) {
+	base := writeFile(t, "base.yaml", `
+service_name: orders
+timeout: 30s
+known_peers: [svc-a, svc-b]
+labels:
+  team: core
+  env: dev
+db:
+  host: db
+  port: 5432
+  password: base-secret
+replicas:
+  - host: replica-1
+`)
+	overlay := writeFile(t, "prod.toml", `
+timeout = "1m"
+known_peers = ["svc-c"]
+
+[labels]
+env = "prod"
+
+[db]
+password = "prod-secret"
+`)
+	withArgs(t, "-f"+base, "-f"+overlay)
+
+	var conf fileConfig
+	if err := Read(&conf); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expected := fileConfig{
+		Name:       "orders",
+		Timeout:    time.Minute,
+		KnownPeers: []string{"svc-c"},
+		Labels:     map[string]string{"team": "core", "env": "prod"},
+		Db:         fileDbConfig{Host: "db", Port: 5432, Password: NewPassword("prod-secret")},
+		Replicas:   []fileDbConfig{{Host: "replica-1"}},
+	}
+	if !reflect.DeepEqual(expected, conf) {
+		t.Errorf("Expecting %+v, got %+v", expected, conf)
+	}
+}
+
+func TestReadFileFormats(t *testing.T) {
+	type spec struct {
+		name    string
+		file    string
+		content string
+		args    []string
+	}
+	suite := []spec{
+		{name: "json", file: "app.json", content: `{"Db": {"Host": "db", "Password": "secret"}}`},
+		{name: "yaml", file: "app.yml", content: "db:\n  host: db\n  password: secret\n"},
+		{name: "toml", file: "app.toml", content: "[db]\nhost = \"db\"\npassword = \"secret\"\n"},
+		{name: "flag", file: "app.conf", content: "db:\n  host: db\n  password: secret\n", args: []string{"-format=yaml"}},
+	}
+	for _, test := range suite {
+		t.Run(test.name, func(t *testing.T) {
+			withArgs(t, append(test.args, "-f"+writeFile(t, test.file, test.content))...)
+			var conf fileConfig
+			if err := Read(&conf); err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if conf.Db.Host != "db" || conf.Db.Password.Value() != "secret" {
+				t.Errorf("Unexpected configuration %+v, password [%s]", conf, conf.Db.Password.Value())
+			}
+		})
+	}
+}
+
+type fileTagsConfig struct {
+	Host     string `config:"db_host"`
+	Port     int
+	Internal string `config:"-"`
+	Limits   map[string]int
+}
+
+func TestReadFileTags(t *testing.T) {
+	type spec struct {
+		name    string
+		file    string
+		content string
+	}
+	suite := []spec{
+		{name: "json", file: "app.json", content: `{"db_host": "db", "port": 6432, "internal": "set", "Limits": {"orders": 10}}`},
+		{name: "yaml", file: "app.yaml", content: "db_host: db\nport: 6432\ninternal: set\nlimits:\n  orders: 10\n"},
+		{name: "toml", file: "app.toml", content: "db_host = \"db\"\nport = 6432\ninternal = \"set\"\n[limits]\norders = 10\n"},
+	}
+	for _, test := range suite {
+		t.Run(test.name, func(t *testing.T) {
+			withArgs(t, "-f"+writeFile(t, test.file, test.content))
+			conf := fileTagsConfig{Internal: "code"}
+			if err := Read(&conf); err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			expected := fileTagsConfig{Host: "db", Port: 6432, Internal: "code", Limits: map[string]int{"orders": 10}}
+			if !reflect.DeepEqual(expected, conf) {
+				t.Errorf("Expecting %+v, got %+v", expected, conf)
+			}
+		})
+	}
+}
+
+// level json.Unmarshaler accepting the names and the numbers
+type level int
+
+func (l *level) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return json.Unmarshal(data, (*int)(l))
+	}
+	if name != "high" {
+		return fmt.Errorf("unknown level %s", name)
+	}
+	*l = 2
+	return nil
+}
+
+type fileJsonConfig struct {
+	Host     string `json:"db_host"`
+	Internal string `json:"-"`
+	Timeout  time.Duration
+	Password Password
+	Levels   []level
+}
+
+func TestReadFileJson(t *testing.T) {
+	// a file written for encoding/json
+	withArgs(t, "-f"+writeFile(t, "app.json",
+		`{"db_host": "db", "Internal": "set", "Timeout": 5000000000, "Password": "secret", "Levels": ["high", 1]}`))
+	conf := fileJsonConfig{Internal: "code"}
+	if err := Read(&conf); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if conf.Host != "db" || conf.Internal != "code" || conf.Timeout != 5*time.Second || conf.Password.Value() != "secret" {
+		t.Errorf("Unexpected configuration %+v", conf)
+	}
+	if !reflect.DeepEqual([]level{2, 1}, conf.Levels) {
+		t.Errorf("Expecting the levels [2 1], got %v", conf.Levels)
+	}
+}
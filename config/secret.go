@@ -0,0 +1,207 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var ErrorSecretNotFound = errors.New("secret not found")
+
+// literalPrefix escapes the passwords which look like secret references, it takes precedence over the providers
+const literalPrefix = "literal:"
+
+// SecretProvider resolves the secret references of its scheme, the ref is the reference without the scheme prefix
+type SecretProvider interface {
+	Scheme() string
+	Secret(ref string) (string, error)
+}
+
+var secretProvidersLock sync.RWMutex
+var secretProviders = map[string]SecretProvider{
+	"file": fileSecretProvider{},
+	"env":  envSecretProvider{},
+}
+
+// RegisterSecretProvider adds or replaces the provider of the scheme, must be called before Read
+func RegisterSecretProvider(provider SecretProvider) {
+	secretProvidersLock.Lock()
+	defer secretProvidersLock.Unlock()
+	secretProviders[provider.Scheme()] = provider
+}
+
+func secretProvider(scheme string) SecretProvider {
+	secretProvidersLock.RLock()
+	defer secretProvidersLock.RUnlock()
+	return secretProviders[scheme]
+}
+
+// fileSecretProvider file:/run/secrets/db reads the mounted secret, the trailing line break is removed
+type fileSecretProvider struct{}
+
+func (fileSecretProvider) Scheme() string {
+	return "file"
+}
+
+func (fileSecretProvider) Secret(ref string) (string, error) {
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// envSecretProvider env:DB_PASSWORD reads another environment variable
+type envSecretProvider struct{}
+
+func (envSecretProvider) Scheme() string {
+	return "env"
+}
+
+func (envSecretProvider) Secret(ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("%w: environment variable %s is not set", ErrorSecretNotFound, ref)
+	}
+	return value, nil
+}
+
+// secret resolved value of a reference, shared by the copies of the Password so a refresh updates all of them
+type secret struct {
+	reference string
+	lock      sync.RWMutex
+	value     string
+}
+
+func (s *secret) get() string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.value
+}
+
+func (s *secret) set(value string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.value = value
+}
+
+func (s *secret) resolve() (string, error) {
+	scheme, ref, _ := strings.Cut(s.reference, ":")
+	provider := secretProvider(scheme)
+	if provider == nil {
+		return "", fmt.Errorf("no secret provider for %s", scheme)
+	}
+	value, err := provider.Secret(ref)
+	if err != nil {
+		return "", fmt.Errorf("secret %s: %w", s.reference, err)
+	}
+	return value, nil
+}
+
+// ParsePassword resolves the value if it is a secret reference, a value without a registered scheme is the password.
+// The literal: prefix is removed and the rest is the password
+func ParsePassword(value string) (Password, error) {
+	if strings.HasPrefix(value, literalPrefix) {
+		return NewPassword(strings.TrimPrefix(value, literalPrefix)), nil
+	}
+	scheme, _, found := strings.Cut(value, ":")
+	if !found || secretProvider(scheme) == nil {
+		return NewPassword(value), nil
+	}
+	s := &secret{reference: value}
+	resolved, err := s.resolve()
+	if err != nil {
+		return Password{}, err
+	}
+	s.set(resolved)
+	return Password{secret: s}, nil
+}
+
+// Reference the secret reference the password is resolved from, empty for the literal passwords
+func (p Password) Reference() string {
+	if p.secret == nil {
+		return ""
+	}
+	return p.secret.reference
+}
+
+// RefreshSecrets re-reads the secret references of the configuration so the rotated secrets are picked up. A secret
+// which fails to resolve keeps the previous value, the first error is returned
+func RefreshSecrets(val any) error {
+	_, err := refreshSecrets(reflect.ValueOf(val), "", nil)
+	return err
+}
+
+// refreshSecrets walks the configuration and collects the rotated secrets as changes
+func refreshSecrets(value reflect.Value, key string, changes []Change) ([]Change, error) {
+	var firstErr error
+	keep := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			return changes, nil
+		}
+		return refreshSecrets(value.Elem(), key, changes)
+	case reflect.Struct:
+		if value.Type() == passwordType {
+			password := value.Interface().(Password)
+			if password.secret == nil {
+				return changes, nil
+			}
+			resolved, err := password.secret.resolve()
+			if err != nil {
+				return changes, fmt.Errorf("%s: %w", key, err)
+			}
+			if previous := password.secret.get(); previous != resolved {
+				password.secret.set(resolved)
+				changes = append(changes, Change{
+					Key:        key,
+					Old:        NewPassword(previous),
+					New:        NewPassword(resolved),
+					Reloadable: true,
+				})
+			}
+			return changes, nil
+		}
+		t := value.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, ok := fieldName(field)
+			if !ok || !isBindable(field.Type) {
+				continue
+			}
+			fieldKey := joinKey(key, name)
+			if field.Anonymous {
+				fieldKey = key
+			}
+			var err error
+			if changes, err = refreshSecrets(value.Field(i), fieldKey, changes); err != nil {
+				keep(err)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			var err error
+			if changes, err = refreshSecrets(value.Index(i), fmt.Sprintf("%s[%d]", key, i), changes); err != nil {
+				keep(err)
+			}
+		}
+	case reflect.Map:
+		iterator := value.MapRange()
+		for iterator.Next() {
+			var err error
+			elementKey := fmt.Sprintf("%s[%v]", key, iterator.Key().Interface())
+			if changes, err = refreshSecrets(iterator.Value(), elementKey, changes); err != nil {
+				keep(err)
+			}
+		}
+	}
+	return changes, firstErr
+}
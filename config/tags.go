@@ -0,0 +1,281 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+var ErrorRequired = errors.New("required configuration missing")
+
+// RequiredError lists every required field left empty after all the sources are read
+type RequiredError struct {
+	Fields []string
+}
+
+func (e *RequiredError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrorRequired, strings.Join(e.Fields, ", "))
+}
+
+func (e *RequiredError) Is(target error) bool {
+	return target == ErrorRequired
+}
+
+// fieldName name of the field in the keys, the config tag or the Go name. Returns false for the skipped fields
+func fieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	name := field.Tag.Get("config")
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+// findField finds the field by the config name or the Go name ignoring the case, underscores and dashes, so
+// known_peers finds KnownPeers. Fields of the embedded structs are searched after the own fields
+func findField(conf reflect.Value, name string) reflect.Value {
+	t := conf.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if fieldNameOf, ok := fieldName(t.Field(i)); ok && (sameName(fieldNameOf, name) || sameName(t.Field(i).Name, name)) {
+			return conf.Field(i)
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Anonymous && t.Field(i).IsExported() {
+			embedded := indirect(conf.Field(i))
+			if embedded.Kind() == reflect.Struct {
+				if field := findField(embedded, name); field.IsValid() {
+					return field
+				}
+			}
+		}
+	}
+	return reflect.Value{}
+}
+
+func sameName(a, b string) bool {
+	if strings.EqualFold(a, b) {
+		return true
+	}
+	simplify := strings.NewReplacer("_", "", "-", "")
+	return strings.EqualFold(simplify.Replace(a), simplify.Replace(b))
+}
+
+// isSection fields with nested fields, everything else is set from a single value
+func isSection(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !isValueType(t)
+}
+
+func isBindable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return false
+	}
+	return true
+}
+
+// binding a leaf field: the key as in the command line and the environment variable name
+type binding struct {
+	key   string
+	env   string
+	field reflect.StructField
+}
+
+// bindings lists the leaf fields, the environment variable name is the prefix and the path in upper snake case,
+// DbName in Db is DB_DB_NAME, the env tag is the exact variable name
+func bindings(t reflect.Type, key string, env string, visiting map[reflect.Type]bool) []binding {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if visiting[t] {
+		return nil
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	var result []binding
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := fieldName(field)
+		if !ok || !isBindable(field.Type) {
+			continue
+		}
+		fieldKey := joinKey(key, name)
+		fieldEnv := joinEnv(env, envName(name))
+		if field.Anonymous && isSection(field.Type) {
+			// embedded fields are promoted
+			fieldKey, fieldEnv = key, env
+		}
+		if isSection(field.Type) {
+			result = append(result, bindings(field.Type, fieldKey, fieldEnv, visiting)...)
+			continue
+		}
+		if tag := field.Tag.Get("env"); tag != "" {
+			fieldEnv = tag
+		}
+		result = append(result, binding{key: fieldKey, env: fieldEnv, field: field})
+	}
+	return result
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func joinEnv(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "_" + name
+}
+
+// envName converts the name to upper snake case: KnownPeers is KNOWN_PEERS, HTTPPort is HTTP_PORT
+func envName(name string) string {
+	runes := []rune(name)
+	var builder strings.Builder
+	for i, r := range runes {
+		if r == '.' || r == '-' {
+			r = '_'
+		}
+		if i > 0 && unicode.IsUpper(r) {
+			previous := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+				builder.WriteRune('_')
+			}
+		}
+		builder.WriteRune(unicode.ToUpper(r))
+	}
+	return builder.String()
+}
+
+// fromEnvPrefix reads the environment variables of the configuration fields, only the expected names are looked up
+func fromEnvPrefix(val any, prefix string) error {
+	cfgMap := make(map[string]string)
+	for _, b := range bindings(reflect.TypeOf(val), "", prefix, make(map[reflect.Type]bool)) {
+		if value, ok := os.LookupEnv(b.env); ok && value != "" {
+			cfgMap[b.key] = value
+		}
+	}
+	if len(cfgMap) > 0 {
+		fmt.Println("Reading configuration from environment variables")
+		return updateConfig(val, &cfgMap)
+	}
+	return nil
+}
+
+// applyDefaults sets the default tag values of the empty fields. Nil pointer sections are optional, they are
+// collected to nilSections and get the defaults once a source sets them
+func applyDefaults(conf reflect.Value, nilSections *[]reflect.Value) error {
+	conf = conf.Elem()
+	t := conf.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := fieldName(field)
+		if !ok || !isBindable(field.Type) {
+			continue
+		}
+		value := conf.Field(i)
+		if isSection(field.Type) {
+			if value.Kind() != reflect.Pointer {
+				value = value.Addr()
+			} else if value.IsNil() {
+				*nilSections = append(*nilSections, value)
+				continue
+			}
+			if err := applyDefaults(value, nilSections); err != nil {
+				return fmt.Errorf("%s.%w", name, err)
+			}
+			continue
+		}
+		defaultValue, ok := field.Tag.Lookup("default")
+		if !ok || !value.IsZero() {
+			continue
+		}
+		if err := setValue(value, defaultValue); err != nil {
+			return fmt.Errorf("%s default: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// applySectionDefaults applies the defaults of the sections set by the sources
+func applySectionDefaults(nilSections []reflect.Value) error {
+	for _, section := range nilSections {
+		if section.IsNil() {
+			continue
+		}
+		var nested []reflect.Value
+		if err := applyDefaults(section, &nested); err != nil {
+			return err
+		}
+		if err := applySectionDefaults(nested); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRequired collects the empty required fields, the fields of nil pointer sections are not checked unless the
+// section itself is required
+func checkRequired(conf reflect.Value, key string, env string, missing []string) []string {
+	conf = conf.Elem()
+	t := conf.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := fieldName(field)
+		if !ok || !isBindable(field.Type) {
+			continue
+		}
+		value := conf.Field(i)
+		fieldKey := joinKey(key, name)
+		fieldEnv := joinEnv(env, envName(name))
+		if field.Anonymous && isSection(field.Type) {
+			fieldKey, fieldEnv = key, env
+		}
+		required := field.Tag.Get("required") == "true"
+		if isSection(field.Type) {
+			if value.Kind() == reflect.Pointer {
+				if value.IsNil() {
+					if required {
+						missing = append(missing, fieldKey)
+					}
+					continue
+				}
+			} else {
+				value = value.Addr()
+			}
+			missing = checkRequired(value, fieldKey, fieldEnv, missing)
+			continue
+		}
+		if required && isEmpty(value) {
+			if tag := field.Tag.Get("env"); tag != "" {
+				fieldEnv = tag
+			}
+			missing = append(missing, fmt.Sprintf("%s (%s)", fieldKey, fieldEnv))
+		}
+	}
+	return missing
+}
+
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type tagsDbConfig struct {
+	Host     string `required:"true"`
+	Port     uint16 `default:"5432"`
+	Password Password
+	Url      string `env:"DATABASE_URL"`
+}
+
+type tagsCacheConfig struct {
+	Ttl time.Duration `default:"5s"`
+}
+
+type tagsConfig struct {
+	Name     string        `config:"service_name" required:"true"`
+	Timeout  time.Duration `default:"30s"`
+	Peers    []string
+	Db       tagsDbConfig
+	Cache    *tagsCacheConfig
+	Optional *tagsCacheConfig
+	Internal string `config:"-" default:"x"`
+}
+
+func withArgs(t *testing.T, args ...string) {
+	previous := os.Args
+	os.Args = append([]string{"app"}, args...)
+	t.Cleanup(func() {
+		os.Args = previous
+	})
+}
+
+func TestReadTags(t *testing.T) {
+	withArgs(t, "Db.Port=6432", "Cache.Ttl=0s")
+	t.Setenv("APP_SERVICE_NAME", "orders")
+	t.Setenv("APP_PEERS", "svc-a,svc-b")
+	t.Setenv("APP_DB_HOST", "db")
+	t.Setenv("APP_DB_PASSWORD", "secret")
+	t.Setenv("DATABASE_URL", "postgres://db")
+	t.Setenv("APP_DB_URL", "ignored")
+	t.Setenv("DB_HOST", "ignored")
+
+	var conf tagsConfig
+	if err := ReadWithEnvPrefix(&conf, "APP"); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expected := tagsConfig{
+		Name:    "orders",
+		Timeout: 30 * time.Second,
+		Peers:   []string{"svc-a", "svc-b"},
+		Db: tagsDbConfig{
+			Host:     "db",
+			Port:     6432,
+			Password: NewPassword("secret"),
+			Url:      "postgres://db",
+		},
+		Cache: &tagsCacheConfig{Ttl: 5 * time.Second},
+	}
+	if !reflect.DeepEqual(expected, conf) {
+		t.Errorf("Expecting %+v, got %+v", expected, conf)
+	}
+}
+
+func TestReadRequired(t *testing.T) {
+	withArgs(t)
+	var conf tagsConfig
+	err := ReadWithEnvPrefix(&conf, "APP")
+	if !errors.Is(err, ErrorRequired) {
+		t.Fatalf("Expecting required error, got %v", err)
+	}
+	var requiredError *RequiredError
+	if !errors.As(err, &requiredError) {
+		t.Fatalf("Expecting RequiredError, got %v", err)
+	}
+	expected := []string{"service_name (APP_SERVICE_NAME)", "Db.Host (APP_DB_HOST)"}
+	if !reflect.DeepEqual(expected, requiredError.Fields) {
+		t.Errorf("Expecting %v, got %v", expected, requiredError.Fields)
+	}
+}
+
+func TestConfigName(t *testing.T) {
+	var conf tagsConfig
+	params := map[string]string{"service_name": "orders", "db.host": "db", "Internal": "y"}
+	if err := updateConfig(&conf, &params); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if conf.Name != "orders" || conf.Db.Host != "db" || conf.Internal != "" {
+		t.Errorf("Unexpected configuration %+v", conf)
+	}
+}
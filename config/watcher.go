@@ -0,0 +1,310 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"github.com/rs/zerolog"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const defaultPollInterval = 10 * time.Second
+const defaultSecretsInterval = time.Minute
+
+// Validator is called on the reloaded configuration, the reload is rejected when it fails
+type Validator interface {
+	Validate() error
+}
+
+// WatcherConfiguration the -f files are checked every PollInterval, the secret references are re-read every
+// SecretsInterval (1m by default), negative disables. Sighup reloads on SIGHUP. EnvPrefix must match the one used to
+// read the configuration
+type WatcherConfiguration struct {
+	PollInterval    time.Duration
+	SecretsInterval time.Duration
+	Sighup          bool
+	EnvPrefix       string
+}
+
+// Change of a single field, Key is the path as in the command line keys. Changes of the fields not tagged
+// reload:"true" (or in a section tagged so) are not applied
+type Change struct {
+	Key        string
+	Old        any
+	New        any
+	Reloadable bool
+}
+
+// Event delivered to the subscribers after a reload with changes, Config is the applied configuration
+type Event[T any] struct {
+	Config  *T
+	Changes []Change
+}
+
+// Watcher re-reads the configuration when the files change or on SIGHUP. The reloadable changes are applied to a
+// new snapshot, the others are reported as warnings and keep the old value until restart
+type Watcher[T any] struct {
+	conf    WatcherConfiguration
+	current atomic.Pointer[T]
+
+	mu          sync.Mutex
+	subscribers []func(ctx context.Context, event *Event[T])
+	filesHash   [sha256.Size]byte
+}
+
+// NewWatcher watches the configuration read by Read or ReadWithEnvPrefix, current must not be modified afterwards
+func NewWatcher[T any](current *T, conf *WatcherConfiguration) *Watcher[T] {
+	watcher := Watcher[T]{
+		conf: *conf,
+	}
+	if watcher.conf.PollInterval == 0 {
+		watcher.conf.PollInterval = defaultPollInterval
+	}
+	if watcher.conf.SecretsInterval == 0 {
+		watcher.conf.SecretsInterval = defaultSecretsInterval
+	}
+	watcher.current.Store(current)
+	watcher.filesHash, _ = hashFiles()
+	return &watcher
+}
+
+// Current returns the applied configuration, the snapshot is replaced on reload, never modified
+func (w *Watcher[T]) Current() *T {
+	return w.current.Load()
+}
+
+// Subscribe adds a subscriber, subscribers are called in order from the watcher goroutine. The reload:"true" fields
+// are applied to the snapshot only, a subscriber applies them to the running code, e.g. logger.Subscribe
+func (w *Watcher[T]) Subscribe(subscriber func(ctx context.Context, event *Event[T])) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, subscriber)
+}
+
+// Start watches until the context is done
+func (w *Watcher[T]) Start(ctx context.Context) {
+	var ticks <-chan time.Time
+	if w.conf.PollInterval > 0 {
+		ticker := time.NewTicker(w.conf.PollInterval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+	var secretTicks <-chan time.Time
+	if w.conf.SecretsInterval > 0 {
+		ticker := time.NewTicker(w.conf.SecretsInterval)
+		defer ticker.Stop()
+		secretTicks = ticker.C
+	}
+	var signals chan os.Signal
+	if w.conf.Sighup {
+		signals = make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGHUP)
+		defer signal.Stop(signals)
+	}
+	log := zerolog.Ctx(ctx)
+	log.Info().Msg("configuration watcher started")
+	for {
+		select {
+		case <-ticks:
+			hash, err := hashFiles()
+			if err != nil {
+				log.Warn().Err(err).Msg("configuration files check failed")
+				continue
+			}
+			w.mu.Lock()
+			changed := hash != w.filesHash
+			w.mu.Unlock()
+			if !changed {
+				continue
+			}
+			if err = w.Reload(ctx); err != nil {
+				log.Error().Err(err).Msg("configuration reload failed, keeping the current configuration")
+			}
+		case <-secretTicks:
+			if err := w.RefreshSecrets(ctx); err != nil {
+				log.Error().Err(err).Msg("secrets refresh failed, keeping the current values")
+			}
+		case <-signals:
+			if err := w.Reload(ctx); err != nil {
+				log.Error().Err(err).Msg("configuration reload failed, keeping the current configuration")
+			}
+		case <-ctx.Done():
+			log.Info().Msg("configuration watcher stopped")
+			return
+		}
+	}
+}
+
+// Reload re-reads the files, the environment and the command line, validates the result and notifies the
+// subscribers when anything changed
+func (w *Watcher[T]) Reload(ctx context.Context) error {
+	event, subscribers, err := w.reload(ctx)
+	if event != nil {
+		notify(ctx, event, subscribers)
+	}
+	return err
+}
+
+func (w *Watcher[T]) reload(ctx context.Context) (*Event[T], []func(ctx context.Context, event *Event[T]), error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.filesHash, _ = hashFiles()
+
+	next := new(T)
+	if err := ReadWithEnvPrefix(next, w.conf.EnvPrefix); err != nil {
+		return nil, nil, err
+	}
+	if validator, ok := any(next).(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+
+	current := w.current.Load()
+	changes := diff(reflect.ValueOf(current).Elem(), reflect.ValueOf(next).Elem(), "", false, nil)
+	if len(changes) == 0 {
+		return nil, nil, nil
+	}
+	log := zerolog.Ctx(ctx)
+	for _, change := range changes {
+		if change.Reloadable {
+			log.Info().Msgf("configuration %s changed", change.Key)
+		} else {
+			log.Warn().Msgf("configuration %s changed, restart to apply", change.Key)
+		}
+	}
+	w.current.Store(next)
+	event := Event[T]{
+		Config:  next,
+		Changes: changes,
+	}
+	return &event, append([]func(ctx context.Context, event *Event[T]){}, w.subscribers...), nil
+}
+
+// RefreshSecrets re-reads the secret references of the current configuration, the rotated secrets are applied in
+// place and delivered to the subscribers as reloadable changes
+func (w *Watcher[T]) RefreshSecrets(ctx context.Context) error {
+	event, subscribers, err := w.refreshSecrets(ctx)
+	if event != nil {
+		notify(ctx, event, subscribers)
+	}
+	return err
+}
+
+func (w *Watcher[T]) refreshSecrets(ctx context.Context) (*Event[T], []func(ctx context.Context, event *Event[T]), error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	current := w.current.Load()
+	changes, err := refreshSecrets(reflect.ValueOf(current), "", nil)
+	if len(changes) == 0 {
+		return nil, nil, err
+	}
+	log := zerolog.Ctx(ctx)
+	for _, change := range changes {
+		log.Info().Msgf("secret %s rotated", change.Key)
+	}
+	event := Event[T]{
+		Config:  current,
+		Changes: changes,
+	}
+	return &event, append([]func(ctx context.Context, event *Event[T]){}, w.subscribers...), err
+}
+
+// notify is called without the lock, the subscribers may use the watcher
+func notify[T any](ctx context.Context, event *Event[T], subscribers []func(ctx context.Context, event *Event[T])) {
+	for _, subscriber := range subscribers {
+		subscriber(ctx, event)
+	}
+}
+
+// diff compares the fields of the sections, the changes of the not reloadable fields are reverted in next
+func diff(current, next reflect.Value, key string, reloadable bool, changes []Change) []Change {
+	t := current.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := fieldName(field)
+		if !ok {
+			continue
+		}
+		currentValue, nextValue := current.Field(i), next.Field(i)
+		if !isBindable(field.Type) {
+			// set in the code, not by the configuration sources
+			nextValue.Set(currentValue)
+			continue
+		}
+		fieldKey := joinKey(key, name)
+		fieldReloadable := reloadable || field.Tag.Get("reload") == "true"
+		if field.Type == passwordType {
+			if change, rotated := rotateSecret(currentValue, nextValue, fieldKey); rotated {
+				if change != nil {
+					changes = append(changes, *change)
+				}
+				continue
+			}
+		}
+		if isSection(field.Type) {
+			if field.Type.Kind() != reflect.Pointer {
+				changes = diff(currentValue, nextValue, fieldKey, fieldReloadable, changes)
+				continue
+			}
+			if !currentValue.IsNil() && !nextValue.IsNil() {
+				changes = diff(currentValue.Elem(), nextValue.Elem(), fieldKey, fieldReloadable, changes)
+				continue
+			}
+		}
+		if reflect.DeepEqual(currentValue.Interface(), nextValue.Interface()) {
+			continue
+		}
+		changes = append(changes, Change{
+			Key:        fieldKey,
+			Old:        currentValue.Interface(),
+			New:        nextValue.Interface(),
+			Reloadable: fieldReloadable,
+		})
+		if !fieldReloadable {
+			nextValue.Set(currentValue)
+		}
+	}
+	return changes
+}
+
+// rotateSecret the same secret reference is kept, a rotated value is applied in place as a reloadable change
+func rotateSecret(current, next reflect.Value, key string) (*Change, bool) {
+	currentPassword, nextPassword := current.Interface().(Password), next.Interface().(Password)
+	if currentPassword.secret == nil || nextPassword.secret == nil ||
+		currentPassword.Reference() != nextPassword.Reference() {
+		return nil, false
+	}
+	next.Set(current)
+	previous, resolved := currentPassword.Value(), nextPassword.Value()
+	if previous == resolved {
+		return nil, true
+	}
+	currentPassword.secret.set(resolved)
+	return &Change{
+		Key:        key,
+		Old:        NewPassword(previous),
+		New:        NewPassword(resolved),
+		Reloadable: true,
+	}, true
+}
+
+func hashFiles() ([sha256.Size]byte, error) {
+	hash := sha256.New()
+	for _, fileName := range configFiles() {
+		data, err := os.ReadFile(fileName)
+		if err != nil {
+			return [sha256.Size]byte{}, err
+		}
+		hash.Write(data)
+	}
+	var result [sha256.Size]byte
+	copy(result[:], hash.Sum(nil))
+	return result, nil
+}
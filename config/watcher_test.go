@@ -0,0 +1,192 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type watcherLogConfig struct {
+	Level string `reload:"true"`
+	Mode  string
+}
+
+type watcherConfig struct {
+	Name    string
+	Limit   int `reload:"true"`
+	Log     watcherLogConfig
+	Feature *struct {
+		Enabled bool
+	} `reload:"true"`
+	Store any
+}
+
+func (c *watcherConfig) Validate() error {
+	if c.Limit < 0 {
+		return errors.New("limit must not be negative")
+	}
+	return nil
+}
+
+func TestWatcherReload(t *testing.T) {
+	path := writeFile(t, "app.yaml", `
+name: orders
+limit: 10
+log:
+  level: info
+  mode: json
+`)
+	withArgs(t, "-f"+path)
+	current := &watcherConfig{}
+	if err := Read(current); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	current.Store = "store"
+
+	watcher := NewWatcher(current, &WatcherConfiguration{})
+	var events []*Event[watcherConfig]
+	watcher.Subscribe(func(ctx context.Context, event *Event[watcherConfig]) {
+		events = append(events, event)
+	})
+
+	ctx := context.Background()
+	if err := watcher.Reload(ctx); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(events) != 0 || watcher.Current() != current {
+		t.Fatalf("Expecting no changes, got %v", events)
+	}
+
+	if err := os.WriteFile(path, []byte(`
+name: payments
+limit: 20
+log:
+  level: debug
+  mode: text
+feature:
+  enabled: true
+`), 0600); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := watcher.Reload(ctx); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expecting one event, got %d", len(events))
+	}
+	reloadable := make(map[string]bool)
+	for _, change := range events[0].Changes {
+		reloadable[change.Key] = change.Reloadable
+	}
+	expected := map[string]bool{
+		"Name":      false,
+		"Limit":     true,
+		"Log.Level": true,
+		"Log.Mode":  false,
+		"Feature":   true,
+	}
+	if !reflect.DeepEqual(expected, reloadable) {
+		t.Errorf("Expecting changes %v, got %v", expected, reloadable)
+	}
+
+	applied := watcher.Current()
+	if applied != events[0].Config {
+		t.Errorf("Expecting the event configuration to be current")
+	}
+	if applied.Name != "orders" || applied.Log.Mode != "json" {
+		t.Errorf("Expecting not reloadable fields to keep the values, got %s %s", applied.Name, applied.Log.Mode)
+	}
+	if applied.Limit != 20 || applied.Log.Level != "debug" || applied.Feature == nil || !applied.Feature.Enabled {
+		t.Errorf("Expecting reloadable fields to be applied, got %+v", applied)
+	}
+	if applied.Store != "store" {
+		t.Errorf("Expecting Store to be kept, got %v", applied.Store)
+	}
+	if current.Limit != 10 {
+		t.Errorf("Expecting the previous snapshot to stay unchanged, got %d", current.Limit)
+	}
+
+	if err := os.WriteFile(path, []byte("limit: -1\n"), 0600); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := watcher.Reload(ctx); err == nil {
+		t.Errorf("Expecting validation error")
+	}
+	if watcher.Current() != applied || len(events) != 1 {
+		t.Errorf("Expecting the invalid configuration to be rejected")
+	}
+}
+
+func TestWatcherSecrets(t *testing.T) {
+	dbSecret := writeFile(t, "db", "first")
+	withArgs(t, "Password=file:"+dbSecret)
+	current := &DbConfig{}
+	if err := Read(current); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	watcher := NewWatcher(current, &WatcherConfiguration{})
+	var events []*Event[DbConfig]
+	watcher.Subscribe(func(ctx context.Context, event *Event[DbConfig]) {
+		events = append(events, event)
+	})
+
+	if err := os.WriteFile(dbSecret, []byte("second"), 0600); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := watcher.Reload(context.Background()); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(events) != 1 || len(events[0].Changes) != 1 || !events[0].Changes[0].Reloadable {
+		t.Fatalf("Expecting a reloadable secret change, got %v", events)
+	}
+	if current.Password.Value() != "second" || watcher.Current().Password.Value() != "second" {
+		t.Errorf("Expecting the rotated secret to be applied in place")
+	}
+
+	if err := os.WriteFile(dbSecret, []byte("third"), 0600); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := watcher.RefreshSecrets(context.Background()); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(events) != 2 || events[1].Changes[0].Key != "Password" || current.Password.Value() != "third" {
+		t.Errorf("Expecting the refreshed secret, got %v", events)
+	}
+}
+
+func TestWatcherSubscriberUsesWatcher(t *testing.T) {
+	path := writeFile(t, "app.yaml", "limit: 10\n")
+	withArgs(t, "-f"+path)
+	current := &watcherConfig{}
+	if err := Read(current); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	watcher := NewWatcher(current, &WatcherConfiguration{})
+	var limit int
+	watcher.Subscribe(func(ctx context.Context, event *Event[watcherConfig]) {
+		// a subscriber may read the configuration and subscribe again
+		limit = watcher.Current().Limit
+		watcher.Subscribe(func(ctx context.Context, event *Event[watcherConfig]) {})
+	})
+	if err := os.WriteFile(path, []byte("limit: 20\n"), 0600); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	done := make(chan error)
+	go func() {
+		done <- watcher.Reload(context.Background())
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Reload is blocked by the subscriber")
+	}
+	if limit != 20 {
+		t.Errorf("Expecting the reloaded limit, got %d", limit)
+	}
+}
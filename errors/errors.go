@@ -0,0 +1,121 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Error kinds, use errors.Is to check the kind of typed errors
+var ErrorNotFound = errors.New("not found")
+var ErrorConflict = errors.New("conflict")
+var ErrorValidation = errors.New("validation failed")
+var ErrorPermissionDenied = errors.New("permission denied")
+var ErrorUnavailable = errors.New("unavailable")
+
+type NotFoundError struct {
+	Resource string
+	Id       string
+}
+
+func NotFound(resource string, id string) error {
+	return &NotFoundError{
+		Resource: resource,
+		Id:       id,
+	}
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %s not found", e.Resource, e.Id)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrorNotFound
+}
+
+type ConflictError struct {
+	Resource string
+	Reason   string
+}
+
+func Conflict(resource string, reason string) error {
+	return &ConflictError{
+		Resource: resource,
+		Reason:   reason,
+	}
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s conflict: %s", e.Resource, e.Reason)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrorConflict
+}
+
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+func Validation(violations ...FieldViolation) error {
+	return &ValidationError{
+		Violations: violations,
+	}
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		fields = append(fields, fmt.Sprintf("%s: %s", violation.Field, violation.Description))
+	}
+	return fmt.Sprintf("validation failed [%s]", strings.Join(fields, ", "))
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrorValidation
+}
+
+type PermissionDeniedError struct {
+	Reason string
+}
+
+func PermissionDenied(reason string) error {
+	return &PermissionDeniedError{
+		Reason: reason,
+	}
+}
+
+func (e *PermissionDeniedError) Error() string {
+	return fmt.Sprintf("permission denied: %s", e.Reason)
+}
+
+func (e *PermissionDeniedError) Is(target error) bool {
+	return target == ErrorPermissionDenied
+}
+
+// UnavailableError the caller may retry after RetryAfter, zero means the caller decides
+type UnavailableError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func Unavailable(reason string, retryAfter time.Duration) error {
+	return &UnavailableError{
+		Reason:     reason,
+		RetryAfter: retryAfter,
+	}
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("unavailable: %s", e.Reason)
+}
+
+func (e *UnavailableError) Is(target error) bool {
+	return target == ErrorUnavailable
+}
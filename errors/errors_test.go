@@ -0,0 +1,52 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestErrorKinds(t *testing.T) {
+	type spec struct {
+		name string
+		err  error
+		kind error
+	}
+	suite := []spec{
+		{"not found", NotFound("order", "42"), ErrorNotFound},
+		{"conflict", Conflict("order", "already paid"), ErrorConflict},
+		{"validation", Validation(FieldViolation{Field: "limit", Description: "too big"}), ErrorValidation},
+		{"permission denied", PermissionDenied("not an owner"), ErrorPermissionDenied},
+		{"unavailable", Unavailable("db is down", time.Second), ErrorUnavailable},
+	}
+	kinds := []error{ErrorNotFound, ErrorConflict, ErrorValidation, ErrorPermissionDenied, ErrorUnavailable}
+	for _, test := range suite {
+		t.Run(test.name, func(t *testing.T) {
+			wrapped := fmt.Errorf("handler failed: %w", test.err)
+			for _, kind := range kinds {
+				if errors.Is(wrapped, kind) != (kind == test.kind) {
+					t.Errorf("errors.Is(%v, %v) expecting %t", wrapped, kind, kind == test.kind)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorAs(t *testing.T) {
+	err := fmt.Errorf("handler failed: %w", Validation(
+		FieldViolation{Field: "limit", Description: "too big"},
+		FieldViolation{Field: "id", Description: "required"},
+	))
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expecting ValidationError")
+	}
+	if len(validationErr.Violations) != 2 {
+		t.Errorf("Expecting 2 violations, got %d", len(validationErr.Violations))
+	}
+	expected := "validation failed [limit: too big, id: required]"
+	if validationErr.Error() != expected {
+		t.Errorf("Expecting [%s], got [%s]", expected, validationErr.Error())
+	}
+}
@@ -0,0 +1,107 @@
+package grpc
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/iyarkov/kit/grpc/protobuf"
+	"github.com/iyarkov/kit/logger"
+	kitsql "github.com/iyarkov/kit/sql"
+	"github.com/iyarkov/kit/support"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var adminPolicies = []string{"/iyarkov.kit.Admin/*", "/grpc.reflection.v1alpha.ServerReflection/*"}
+
+// adminPolicy admins from any peer, operators from the trusted peers only
+var adminPolicy = Policy{
+	Roles:        []string{"admin"},
+	TrustedRoles: []string{"operator"},
+}
+
+type adminServer struct {
+	protobuf.UnimplementedAdminServer
+	appConfig any
+	db        *sql.DB
+}
+
+// RegisterAdmin registers the admin service and the server reflection, requires EnableAdmin. The appConfig is
+// exposed as JSON with config.Password values redacted, db is the source of the schema history, both may be nil
+func (s *Server) RegisterAdmin(ctx context.Context, appConfig any, db *sql.DB) error {
+	if !s.cfg.EnableAdmin {
+		return fmt.Errorf("admin service is not enabled")
+	}
+	protobuf.RegisterAdminServer(s.Server, &adminServer{
+		appConfig: appConfig,
+		db:        db,
+	})
+	reflection.Register(s.Server)
+	zerolog.Ctx(ctx).Info().Msg("admin service and reflection registered")
+	return nil
+}
+
+func (a *adminServer) GetLogLevel(ctx context.Context, _ *protobuf.GetLogLevelRequest) (*protobuf.LogLevel, error) {
+	return &protobuf.LogLevel{Level: logger.Level()}, nil
+}
+
+func (a *adminServer) SetLogLevel(ctx context.Context, req *protobuf.LogLevel) (*protobuf.LogLevel, error) {
+	previous := logger.Level()
+	if err := logger.SetLevel(req.Level); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	zerolog.Ctx(ctx).Warn().Msgf("admin: log level changed from %s to %s", previous, req.Level)
+	return &protobuf.LogLevel{Level: logger.Level()}, nil
+}
+
+func (a *adminServer) GetManifest(ctx context.Context, _ *protobuf.GetManifestRequest) (*protobuf.Manifest, error) {
+	return &protobuf.Manifest{
+		Instance:  support.AppManifest.Instance,
+		Name:      support.AppManifest.Name,
+		Version:   support.AppManifest.Version,
+		Namespace: support.AppManifest.Namespace,
+	}, nil
+}
+
+func (a *adminServer) GetSchemaHistory(ctx context.Context, _ *protobuf.GetSchemaHistoryRequest) (*protobuf.SchemaHistory, error) {
+	if a.db == nil {
+		return nil, status.Error(codes.Unimplemented, "no database")
+	}
+	history, err := kitsql.LoadHistory(ctx, a.db)
+	if err != nil {
+		return nil, err
+	}
+	result := protobuf.SchemaHistory{
+		Changes: make([]*protobuf.SchemaChange, 0, len(history)),
+	}
+	for _, record := range history {
+		result.Changes = append(result.Changes, &protobuf.SchemaChange{
+			Id:        record.Id,
+			Version:   record.Version,
+			CreatedAt: timestamppb.New(record.CreatedAt),
+		})
+	}
+	return &result, nil
+}
+
+func (a *adminServer) GetConfig(ctx context.Context, _ *protobuf.GetConfigRequest) (*structpb.Struct, error) {
+	if a.appConfig == nil {
+		return nil, status.Error(codes.Unimplemented, "no configuration")
+	}
+	// config.Password marshals as "***"
+	buffer, err := json.Marshal(a.appConfig)
+	if err != nil {
+		return nil, err
+	}
+	var result structpb.Struct
+	if err = protojson.Unmarshal(buffer, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
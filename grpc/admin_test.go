@@ -0,0 +1,71 @@
+package grpc
+
+import (
+	"context"
+	"github.com/iyarkov/kit/config"
+	"github.com/iyarkov/kit/grpc/protobuf"
+	"github.com/iyarkov/kit/logger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestAdminLogLevel(t *testing.T) {
+	previous := logger.Level()
+	defer func() {
+		_ = logger.SetLevel(previous)
+	}()
+	admin := adminServer{}
+	ctx := context.Background()
+
+	level, err := admin.SetLogLevel(ctx, &protobuf.LogLevel{Level: "warn"})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if level.Level != "warn" {
+		t.Errorf("Expecting warn, got %s", level.Level)
+	}
+	if level, _ = admin.GetLogLevel(ctx, &protobuf.GetLogLevelRequest{}); level.Level != "warn" {
+		t.Errorf("Expecting warn, got %s", level.Level)
+	}
+	if _, err = admin.SetLogLevel(ctx, &protobuf.LogLevel{Level: "loud"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expecting InvalidArgument, got %v", err)
+	}
+}
+
+func TestAdminConfig(t *testing.T) {
+	type dbConfig struct {
+		User     string
+		Password config.Password
+	}
+	admin := adminServer{
+		appConfig: &struct {
+			Name string
+			Db   dbConfig
+		}{
+			Name: "test",
+			Db:   dbConfig{User: "app", Password: config.NewPassword("secret")},
+		},
+	}
+	result, err := admin.GetConfig(context.Background(), &protobuf.GetConfigRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if name := result.Fields["Name"].GetStringValue(); name != "test" {
+		t.Errorf("Expecting test, got %s", name)
+	}
+	db := result.Fields["Db"].GetStructValue()
+	if user := db.Fields["User"].GetStringValue(); user != "app" {
+		t.Errorf("Expecting app, got %s", user)
+	}
+	if password := db.Fields["Password"].GetStringValue(); password != "***" {
+		t.Errorf("Password is not redacted: %s", password)
+	}
+}
+
+func TestAdminNoDatabase(t *testing.T) {
+	admin := adminServer{}
+	if _, err := admin.GetSchemaHistory(context.Background(), &protobuf.GetSchemaHistoryRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expecting Unimplemented, got %v", err)
+	}
+}
@@ -0,0 +1,235 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	kiterrors "github.com/iyarkov/kit/errors"
+	"github.com/iyarkov/kit/support"
+	"github.com/rs/zerolog"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"time"
+)
+
+// ErrorInfo reasons and metadata keys
+const (
+	reasonNotFound         = "NOT_FOUND"
+	reasonConflict         = "CONFLICT"
+	reasonPermissionDenied = "PERMISSION_DENIED"
+	reasonUnavailable      = "UNAVAILABLE"
+	metaResource           = "resource"
+	metaId                 = "id"
+	metaReason             = "reason"
+)
+
+// ToStatus converts the kit errors into gRPC statuses with error details. Status errors are returned as is, any
+// other error becomes Internal without leaking the error message
+func ToStatus(err error) *status.Status {
+	if err == nil {
+		return nil
+	}
+	var notFound *kiterrors.NotFoundError
+	var conflict *kiterrors.ConflictError
+	var validation *kiterrors.ValidationError
+	var permissionDenied *kiterrors.PermissionDeniedError
+	var unavailable *kiterrors.UnavailableError
+	switch {
+	case errors.As(err, &notFound):
+		return withDetails(codes.NotFound, notFound.Error(), errorInfo(reasonNotFound, map[string]string{
+			metaResource: notFound.Resource,
+			metaId:       notFound.Id,
+		}))
+	case errors.As(err, &conflict):
+		return withDetails(codes.AlreadyExists, conflict.Error(), errorInfo(reasonConflict, map[string]string{
+			metaResource: conflict.Resource,
+			metaReason:   conflict.Reason,
+		}))
+	case errors.As(err, &validation):
+		badRequest := errdetails.BadRequest{}
+		for _, violation := range validation.Violations {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       violation.Field,
+				Description: violation.Description,
+			})
+		}
+		return withDetails(codes.InvalidArgument, validation.Error(), &badRequest)
+	case errors.As(err, &permissionDenied):
+		return withDetails(codes.PermissionDenied, permissionDenied.Error(), errorInfo(reasonPermissionDenied, map[string]string{
+			metaReason: permissionDenied.Reason,
+		}))
+	case errors.As(err, &unavailable):
+		details := []protoiface.MessageV1{errorInfo(reasonUnavailable, map[string]string{
+			metaReason: unavailable.Reason,
+		})}
+		if unavailable.RetryAfter > 0 {
+			details = append(details, &errdetails.RetryInfo{
+				RetryDelay: durationpb.New(unavailable.RetryAfter),
+			})
+		}
+		return withDetails(codes.Unavailable, unavailable.Error(), details...)
+	}
+	if grpcStatus, ok := status.FromError(err); ok {
+		return grpcStatus
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.New(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.New(codes.DeadlineExceeded, err.Error())
+	}
+	return status.New(codes.Internal, "internal error")
+}
+
+func errorInfo(reason string, meta map[string]string) *errdetails.ErrorInfo {
+	return &errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   support.AppManifest.Name,
+		Metadata: meta,
+	}
+}
+
+func withDetails(code codes.Code, msg string, details ...protoiface.MessageV1) *status.Status {
+	grpcStatus := status.New(code, msg)
+	detailed, err := grpcStatus.WithDetails(details...)
+	if err != nil {
+		return grpcStatus
+	}
+	return detailed
+}
+
+// ServerErrors Server Side Interceptor, converts the handler errors with ToStatus. Internal errors are logged
+func ServerErrors(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, toStatusError(ctx, err)
+	}
+	return resp, nil
+}
+
+// StreamServerErrors Server Side Stream Interceptor, see ServerErrors
+func StreamServerErrors(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	err := handler(srv, stream)
+	if err != nil {
+		return toStatusError(stream.Context(), err)
+	}
+	return nil
+}
+
+func toStatusError(ctx context.Context, err error) error {
+	grpcStatus := ToStatus(err)
+	if grpcStatus.Code() == codes.Internal {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("internal error")
+	}
+	return grpcStatus.Err()
+}
+
+// statusError keeps the original status so status.Code works with the errors returned by FromError
+type statusError struct {
+	err    error
+	status *status.Status
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+func (e *statusError) GRPCStatus() *status.Status {
+	return e.status
+}
+
+// FromError converts the statuses created by ToStatus back into the kit errors. Only the statuses with the kit
+// ErrorInfo reason or BadRequest details are converted, the other errors are returned as is
+func FromError(err error) error {
+	grpcStatus, ok := status.FromError(err)
+	if !ok || grpcStatus == nil {
+		return err
+	}
+	reason := ""
+	badRequest := false
+	meta := make(map[string]string)
+	var violations []kiterrors.FieldViolation
+	var retryAfter time.Duration
+	for _, detail := range grpcStatus.Details() {
+		switch typed := detail.(type) {
+		case *errdetails.ErrorInfo:
+			reason = typed.Reason
+			for key, value := range typed.Metadata {
+				meta[key] = value
+			}
+		case *errdetails.BadRequest:
+			badRequest = true
+			for _, violation := range typed.FieldViolations {
+				violations = append(violations, kiterrors.FieldViolation{
+					Field:       violation.Field,
+					Description: violation.Description,
+				})
+			}
+		case *errdetails.RetryInfo:
+			retryAfter = typed.RetryDelay.AsDuration()
+		}
+	}
+
+	var result error
+	switch {
+	case grpcStatus.Code() == codes.NotFound && reason == reasonNotFound:
+		result = kiterrors.NotFound(meta[metaResource], meta[metaId])
+	case grpcStatus.Code() == codes.AlreadyExists && reason == reasonConflict:
+		result = kiterrors.Conflict(meta[metaResource], meta[metaReason])
+	case grpcStatus.Code() == codes.InvalidArgument && badRequest:
+		result = kiterrors.Validation(violations...)
+	case grpcStatus.Code() == codes.PermissionDenied && reason == reasonPermissionDenied:
+		result = kiterrors.PermissionDenied(valueOr(meta[metaReason], grpcStatus.Message()))
+	case grpcStatus.Code() == codes.Unavailable && reason == reasonUnavailable:
+		result = kiterrors.Unavailable(valueOr(meta[metaReason], grpcStatus.Message()), retryAfter)
+	default:
+		return err
+	}
+	return &statusError{
+		err:    result,
+		status: grpcStatus,
+	}
+}
+
+func valueOr(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// ClientErrors Client Side Interceptor, converts the call errors with FromError
+func ClientErrors(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return FromError(invoker(ctx, method, req, reply, cc, opts...))
+}
+
+// errorsClientStream converts the stream errors with FromError
+type errorsClientStream struct {
+	grpc.ClientStream
+}
+
+func (s *errorsClientStream) SendMsg(m interface{}) error {
+	return FromError(s.ClientStream.SendMsg(m))
+}
+
+func (s *errorsClientStream) RecvMsg(m interface{}) error {
+	return FromError(s.ClientStream.RecvMsg(m))
+}
+
+// StreamClientErrors Client Side Stream Interceptor, see ClientErrors
+func StreamClientErrors(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		return nil, FromError(err)
+	}
+	return &errorsClientStream{
+		ClientStream: stream,
+	}, nil
+}
@@ -0,0 +1,115 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	kiterrors "github.com/iyarkov/kit/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestErrorsRoundTrip(t *testing.T) {
+	type spec struct {
+		name string
+		err  error
+		code codes.Code
+		kind error
+	}
+	suite := []spec{
+		{"not found", kiterrors.NotFound("order", "42"), codes.NotFound, kiterrors.ErrorNotFound},
+		{"conflict", kiterrors.Conflict("order", "already paid"), codes.AlreadyExists, kiterrors.ErrorConflict},
+		{"validation", kiterrors.Validation(
+			kiterrors.FieldViolation{Field: "limit", Description: "too big"},
+			kiterrors.FieldViolation{Field: "id", Description: "required"},
+		), codes.InvalidArgument, kiterrors.ErrorValidation},
+		{"permission denied", kiterrors.PermissionDenied("not an owner"), codes.PermissionDenied, kiterrors.ErrorPermissionDenied},
+		{"unavailable", kiterrors.Unavailable("db is down", 3*time.Second), codes.Unavailable, kiterrors.ErrorUnavailable},
+	}
+	for _, test := range suite {
+		t.Run(test.name, func(t *testing.T) {
+			serverErr := ToStatus(fmt.Errorf("handler failed: %w", test.err)).Err()
+			if status.Code(serverErr) != test.code {
+				t.Errorf("Expecting code %s, got %s", test.code, status.Code(serverErr))
+			}
+
+			clientErr := FromError(serverErr)
+			if !errors.Is(clientErr, test.kind) {
+				t.Errorf("Expecting %v to be %v", clientErr, test.kind)
+			}
+			if status.Code(clientErr) != test.code {
+				t.Errorf("Expecting client code %s, got %s", test.code, status.Code(clientErr))
+			}
+			if !reflect.DeepEqual(errors.Unwrap(clientErr), test.err) {
+				t.Errorf("Expecting %#v, got %#v", test.err, errors.Unwrap(clientErr))
+			}
+		})
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	type spec struct {
+		name    string
+		err     error
+		code    codes.Code
+		message string
+	}
+	suite := []spec{
+		{"status", status.Error(codes.FailedPrecondition, "not ready"), codes.FailedPrecondition, "not ready"},
+		{"canceled", fmt.Errorf("query failed: %w", context.Canceled), codes.Canceled, "query failed: context canceled"},
+		{"deadline", context.DeadlineExceeded, codes.DeadlineExceeded, "context deadline exceeded"},
+		{"internal", errors.New("password=secret"), codes.Internal, "internal error"},
+	}
+	for _, test := range suite {
+		t.Run(test.name, func(t *testing.T) {
+			result := ToStatus(test.err)
+			if result.Code() != test.code {
+				t.Errorf("Expecting code %s, got %s", test.code, result.Code())
+			}
+			if result.Message() != test.message {
+				t.Errorf("Expecting message [%s], got [%s]", test.message, result.Message())
+			}
+		})
+	}
+}
+
+func TestFromErrorPassThrough(t *testing.T) {
+	err := status.Error(codes.Internal, "internal error")
+	if FromError(err) != err {
+		t.Errorf("Expecting internal error to be returned as is")
+	}
+	if FromError(nil) != nil {
+		t.Errorf("Expecting nil")
+	}
+
+	// statuses of the servers not using the kit errors keep the code and the message
+	for _, code := range []codes.Code{codes.NotFound, codes.AlreadyExists, codes.InvalidArgument, codes.PermissionDenied, codes.Unavailable} {
+		err = status.Error(code, "upstream message")
+		if FromError(err) != err {
+			t.Errorf("Expecting %s without details to be returned as is, got %v", code, FromError(err))
+		}
+	}
+	err = withDetails(codes.NotFound, "upstream message", errorInfo("OTHER", nil)).Err()
+	if FromError(err) != err {
+		t.Errorf("Expecting a foreign ErrorInfo reason to be returned as is")
+	}
+}
+
+func TestToStatusRetryInfo(t *testing.T) {
+	for _, retryAfter := range []time.Duration{0, time.Second} {
+		details := ToStatus(kiterrors.Unavailable("db is down", retryAfter)).Details()
+		retryInfo := 0
+		for _, detail := range details {
+			if _, ok := detail.(*errdetails.RetryInfo); ok {
+				retryInfo++
+			}
+		}
+		if expected := map[bool]int{false: 0, true: 1}[retryAfter > 0]; retryInfo != expected {
+			t.Errorf("Expecting %d RetryInfo details for %s, got %d", expected, retryAfter, retryInfo)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"github.com/iyarkov/kit/auth"
+	"github.com/iyarkov/kit/idempotency"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"time"
+)
+
+const (
+	defaultIdempotencyTtl         = 24 * time.Hour
+	defaultIdempotencyLockTimeout = time.Minute
+	maxIdempotencyKeyLength       = 255
+)
+
+var idempotencyKeyMeta = "idempotencyKey"
+
+// IdempotencyConfiguration successful responses are kept for Ttl, a request in flight holds its key for LockTimeout,
+// a duplicate arriving after LockTimeout runs again. Store is not loaded from the configuration, set it in the code
+type IdempotencyConfiguration struct {
+	Ttl         time.Duration
+	LockTimeout time.Duration
+	Store       idempotency.Store `config:"-"`
+}
+
+// WithIdempotencyKey sends the key with the calls made with the context, retries of the call share the key
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, idempotencyKeyMeta, key)
+}
+
+type idempotencyInterceptor struct {
+	store       idempotency.Store
+	ttl         time.Duration
+	lockTimeout time.Duration
+}
+
+// NewServerIdempotencyInterceptor Server Side Interceptor for the calls with the idempotencyKey metadata. The
+// response of a successful call is stored per (account, method, key) and returned to the duplicates, a duplicate of
+// a call in flight is ABORTED, the same key with a different request is INVALID_ARGUMENT. Failed calls are not
+// stored. Calls without the key are passed as is
+func NewServerIdempotencyInterceptor(initCtx context.Context, conf *IdempotencyConfiguration) (grpc.UnaryServerInterceptor, error) {
+	if conf.Store == nil {
+		return nil, errors.New("idempotency store is not set")
+	}
+	interceptor := idempotencyInterceptor{
+		store:       conf.Store,
+		ttl:         conf.Ttl,
+		lockTimeout: conf.LockTimeout,
+	}
+	if interceptor.ttl <= 0 {
+		interceptor.ttl = defaultIdempotencyTtl
+	}
+	if interceptor.lockTimeout <= 0 {
+		interceptor.lockTimeout = defaultIdempotencyLockTimeout
+	}
+	zerolog.Ctx(initCtx).Debug().Msg("idempotency interceptor initialized")
+	return interceptor.intercept, nil
+}
+
+func (i *idempotencyInterceptor) intercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	var keyValue string
+	if meta, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := meta.Get(idempotencyKeyMeta); len(values) > 0 {
+			keyValue = values[0]
+		}
+	}
+	if keyValue == "" {
+		return handler(ctx, req)
+	}
+	if len(keyValue) > maxIdempotencyKeyLength {
+		return nil, status.Errorf(codes.InvalidArgument, "idempotency key is longer than %d", maxIdempotencyKeyLength)
+	}
+	token := auth.AuthToken(ctx)
+	if !token.IsAuthenticated() {
+		return nil, status.Error(codes.InvalidArgument, "idempotency key requires an authenticated caller")
+	}
+	message, ok := req.(proto.Message)
+	if !ok {
+		return handler(ctx, req)
+	}
+	requestHash, err := hashRequest(message)
+	if err != nil {
+		return nil, err
+	}
+
+	log := zerolog.Ctx(ctx)
+	key := idempotency.Key{
+		AccountId: token.AccountId,
+		Method:    info.FullMethod,
+		Key:       keyValue,
+	}
+	record, err := i.store.Reserve(ctx, key, requestHash, i.lockTimeout)
+	if err != nil {
+		log.Error().Err(err).Msg("idempotency key reserve failed")
+		return nil, status.Error(codes.Unavailable, "idempotency store unavailable")
+	}
+	if record != nil {
+		return replay(ctx, record, requestHash)
+	}
+
+	resp, err := handler(ctx, req)
+	if err != nil {
+		if releaseErr := i.store.Release(ctx, key); releaseErr != nil {
+			log.Error().Err(releaseErr).Msg("idempotency key release failed")
+		}
+		return resp, err
+	}
+	response, ok := resp.(proto.Message)
+	if !ok {
+		return resp, nil
+	}
+	stored, err := anypb.New(response)
+	if err == nil {
+		var buffer []byte
+		if buffer, err = proto.Marshal(stored); err == nil {
+			err = i.store.Complete(ctx, key, buffer, i.ttl)
+		}
+	}
+	if err != nil {
+		// the call is done, the duplicates run again once the claim expires
+		log.Error().Err(err).Msg("idempotency response store failed")
+	}
+	return resp, nil
+}
+
+func replay(ctx context.Context, record *idempotency.Record, requestHash []byte) (interface{}, error) {
+	if err := record.Check(requestHash); err != nil {
+		if errors.Is(err, idempotency.ErrorInFlight) {
+			return nil, status.Error(codes.Aborted, "request with the same idempotency key is in flight")
+		}
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var stored anypb.Any
+	if err := proto.Unmarshal(record.Response, &stored); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("stored idempotent response is corrupted")
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+	response, err := stored.UnmarshalNew()
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("stored idempotent response is corrupted")
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+	zerolog.Ctx(ctx).Debug().Msg("idempotent response replayed")
+	return response, nil
+}
+
+func hashRequest(message proto.Message) ([]byte, error) {
+	buffer, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "unable to hash the request: %v", err)
+	}
+	hash := sha256.Sum256(buffer)
+	return hash[:], nil
+}
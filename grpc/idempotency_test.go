@@ -0,0 +1,118 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/iyarkov/kit/auth"
+	"github.com/iyarkov/kit/config"
+	"github.com/iyarkov/kit/idempotency"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestServerIdempotency(t *testing.T) {
+	unary, err := NewServerIdempotencyInterceptor(context.Background(), &IdempotencyConfiguration{
+		Store: idempotency.NewMemoryStore(),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	withKey := func(accountId uint64, key string) context.Context {
+		ctx := auth.WithToken(context.Background(), &auth.Token{AccountId: accountId, Role: auth.User, ExpiresAt: time.Now().Add(time.Hour)})
+		return metadata.NewIncomingContext(ctx, metadata.Pairs(idempotencyKeyMeta, key))
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/kit.Orders/Create"}
+	calls := 0
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return wrapperspb.Int64(int64(calls)), nil
+	}
+	request := wrapperspb.String("order")
+
+	first, err := unary(withKey(1, "a"), request, info, handler)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	replayed, err := unary(withKey(1, "a"), request, info, handler)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if calls != 1 || replayed.(*wrapperspb.Int64Value).Value != first.(*wrapperspb.Int64Value).Value {
+		t.Errorf("Expecting the stored response, got %v after %d calls", replayed, calls)
+	}
+	if _, err = unary(withKey(2, "a"), request, info, handler); err != nil || calls != 2 {
+		t.Errorf("Other accounts keys must not collide, calls %d, error %v", calls, err)
+	}
+	if _, err = unary(withKey(1, "a"), wrapperspb.String("other"), info, handler); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expecting InvalidArgument, got %v", err)
+	}
+
+	started := make(chan bool)
+	finish := make(chan bool)
+	go func() {
+		_, _ = unary(withKey(1, "slow"), request, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			close(started)
+			<-finish
+			return wrapperspb.Int64(0), nil
+		})
+	}()
+	<-started
+	if _, err = unary(withKey(1, "slow"), request, info, handler); status.Code(err) != codes.Aborted {
+		t.Errorf("Expecting Aborted, got %v", err)
+	}
+	close(finish)
+
+	failing := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.New("failed")
+	}
+	if _, err = unary(withKey(1, "failed"), request, info, failing); err == nil {
+		t.Fatalf("Expecting an error")
+	}
+	calls = 0
+	if _, err = unary(withKey(1, "failed"), request, info, handler); err != nil || calls != 1 {
+		t.Errorf("Failed calls must run again, calls %d, error %v", calls, err)
+	}
+}
+
+func TestServerIdempotencyAnonymous(t *testing.T) {
+	unary, err := NewServerIdempotencyInterceptor(context.Background(), &IdempotencyConfiguration{
+		Store: idempotency.NewMemoryStore(),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(idempotencyKeyMeta, "a"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/kit.Orders/Create"}
+	_, err = unary(ctx, wrapperspb.String("order"), info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return wrapperspb.Int64(1), nil
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expecting InvalidArgument, got %v", err)
+	}
+}
+
+func TestIdempotencyConfigurationRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(path, []byte("ttl: 1h\nstore: memory\n"), 0600); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	previous := os.Args
+	os.Args = []string{"app", "-f" + path, "Store=memory"}
+	defer func() { os.Args = previous }()
+
+	store := idempotency.NewMemoryStore()
+	conf := IdempotencyConfiguration{Store: store}
+	if err := config.Read(&conf); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if conf.Ttl != time.Hour || conf.Store != store {
+		t.Errorf("Unexpected configuration %+v", conf)
+	}
+}
@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/iyarkov/kit/auth"
@@ -11,23 +12,28 @@ import (
 	"github.com/iyarkov/kit/tls"
 	"github.com/rs/zerolog"
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
-	"time"
+	"strings"
 )
 
 var contextIdMeta = "contextId"
 var authTokenMeta = "authToken"
 
 type connectionInfoCtxKey struct{}
+type trustedPeerCtxKey struct{}
 
 // ServerContextId Server Side Interceptor
 func ServerContextId(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	return handler(withIncomingContextId(ctx), req)
+}
+
+func withIncomingContextId(ctx context.Context) context.Context {
 	// Get request ID from the request
 	meta, ok := metadata.FromIncomingContext(ctx)
 	var contextId string
@@ -41,23 +47,33 @@ func ServerContextId(ctx context.Context, req interface{}, info *grpc.UnaryServe
 		contextId = uuid.New().String()
 	}
 
-	ctx = logger.WithContextIdAndLogger(ctx, contextId)
-	return handler(ctx, req)
+	return logger.WithContextIdAndLogger(ctx, contextId)
 }
 
 // ClientContextId Client Side Interceptor
 func ClientContextId(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 	zerolog.Ctx(ctx).Debug().Msg("ClientContextId")
+	return invoker(withOutgoingContextId(ctx), method, req, reply, cc, opts...)
+}
+
+func withOutgoingContextId(ctx context.Context) context.Context {
 	contextId := support.ContextId(ctx)
 	if contextId != "" {
 		ctx = metadata.AppendToOutgoingContext(ctx, contextIdMeta, contextId)
 	}
-
-	return invoker(ctx, method, req, reply, cc, opts...)
+	return ctx
 }
 
 // ServerConnectionInfo Client Side Interceptor
 func ServerConnectionInfo(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	ctx, err = withConnectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func withConnectionInfo(ctx context.Context) (context.Context, error) {
 	log := zerolog.Ctx(ctx)
 	client, ok := peer.FromContext(ctx)
 	tlsInfo, ok := client.AuthInfo.(credentials.TLSInfo)
@@ -67,8 +83,7 @@ func ServerConnectionInfo(ctx context.Context, req interface{}, info *grpc.Unary
 	var connectionInfo tls.ConnectionInfo
 	connectionInfo.FromPeerCertificate(tlsInfo.State.PeerCertificates[0])
 	log.Debug().Msgf("Connection Info %v", connectionInfo)
-	ctx = context.WithValue(ctx, &connectionInfoCtxKey{}, connectionInfo)
-	return handler(ctx, req)
+	return context.WithValue(ctx, &connectionInfoCtxKey{}, connectionInfo), nil
 }
 
 func ConnectionInfo(ctx context.Context) tls.ConnectionInfo {
@@ -78,100 +93,239 @@ func ConnectionInfo(ctx context.Context) tls.ConnectionInfo {
 	return tls.ConnectionInfo{}
 }
 
+// NewServerAuthInterceptor authenticates the caller by the signed auth token. Trusted peers may assert any role,
+// other peers only the roles listed in auth.Configuration.UntrustedRoles. Every rejection is audit logged. The tokens
+// are verified by the auth package verifier. Unknown UntrustedRoles are logged and ignored, NewServerAuthInterceptors
+// rejects them
 func NewServerAuthInterceptor(initCtx context.Context, conf *auth.Configuration) grpc.UnaryServerInterceptor {
-	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-		connectionInfo := ConnectionInfo(ctx)
-		trusted := false
-		for _, p := range conf.TrustedPeers {
-			if p == connectionInfo.Peer {
-				trusted = true
-			}
+	authenticator, err := newAuthenticator(initCtx, conf, nil)
+	if err != nil {
+		zerolog.Ctx(initCtx).Error().Err(err).Msg("auth interceptor: unknown roles are ignored")
+	}
+	return authenticator.intercept
+}
+
+// NewServerAuthInterceptors Server Side unary and stream Interceptors, see NewServerAuthInterceptor. The tokens are
+// verified by the verifier, the auth package verifier when nil
+func NewServerAuthInterceptors(initCtx context.Context, conf *auth.Configuration, verifier auth.Verifier) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor, error) {
+	authenticator, err := newAuthenticator(initCtx, conf, verifier)
+	if err != nil {
+		return nil, nil, err
+	}
+	return authenticator.intercept, authenticator.interceptStream, nil
+}
+
+type authenticator struct {
+	trustedPeers   map[string]bool
+	untrustedRoles map[auth.Role]bool
+	verifier       auth.Verifier
+}
+
+// newAuthenticator the authenticator is returned with the error too, without the unknown roles
+func newAuthenticator(initCtx context.Context, conf *auth.Configuration, verifier auth.Verifier) (*authenticator, error) {
+	result := authenticator{
+		trustedPeers:   make(map[string]bool, len(conf.TrustedPeers)),
+		untrustedRoles: make(map[auth.Role]bool, len(conf.UntrustedRoles)),
+		verifier:       verifier,
+	}
+	for _, p := range conf.TrustedPeers {
+		result.trustedPeers[p] = true
+	}
+	var unknown []string
+	for _, name := range conf.UntrustedRoles {
+		role, ok := auth.RoleByName(name)
+		if !ok {
+			unknown = append(unknown, name)
+			continue
 		}
+		result.untrustedRoles[role] = true
+	}
+	if len(unknown) > 0 {
+		return &result, fmt.Errorf("auth: unknown untrusted roles %s", strings.Join(unknown, ", "))
+	}
+	return &result, nil
+}
 
-		var encodedToken string
-		meta, ok := metadata.FromIncomingContext(ctx)
-		if ok {
-			tokenSlice := meta.Get(authTokenMeta)
-			if len(tokenSlice) > 0 {
-				encodedToken = tokenSlice[0]
-			}
+func (a *authenticator) intercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authenticator) interceptStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, withStreamContext(stream, ctx))
+}
+
+func (a *authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	connectionInfo := ConnectionInfo(ctx)
+	trusted := a.trustedPeers[connectionInfo.Peer]
+	ctx = context.WithValue(ctx, &trustedPeerCtxKey{}, trusted)
+
+	var encodedToken string
+	meta, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		tokenSlice := meta.Get(authTokenMeta)
+		if len(tokenSlice) > 0 {
+			encodedToken = tokenSlice[0]
 		}
-		log := zerolog.Ctx(ctx)
-		if encodedToken == "" {
-			log.Debug().Msgf("auth: client trusted: %t, not authenticated", trusted)
-			return handler(ctx, req)
+	}
+	log := zerolog.Ctx(ctx)
+	if encodedToken == "" {
+		log.Debug().Msgf("auth: client trusted: %t, not authenticated", trusted)
+		return ctx, nil
+	}
+	authCtx, err := a.withToken(ctx, encodedToken)
+	if err != nil {
+		if errors.Is(err, auth.ErrorNoVerifier) {
+			log.Error().Err(err).Msg("auth: tokens can not be verified")
+			return nil, status.Error(codes.Internal, "auth is not configured")
 		}
-		if trusted {
-			ctx, err = auth.WithStringToken(ctx, encodedToken)
-			if err != nil {
-				log.Debug().Err(err).Msgf("invalid token")
-				return nil, status.Error(codes.InvalidArgument, "unable to parse auth token")
-			}
-		} else {
-			log.Debug().Msgf("auth: client not trusted, not  implemented yet")
-			return nil, status.Error(codes.Unimplemented, "non-trusted peers are not implemented yet")
+		auditRejection(ctx, method, connectionInfo, trusted, err.Error())
+		if errors.Is(err, auth.ErrorInvalidSignature) || errors.Is(err, auth.ErrorUnknownKey) || errors.Is(err, auth.ErrorRetiredKey) {
+			return nil, status.Error(codes.Unauthenticated, "invalid auth token signature")
+		}
+		if errors.Is(err, auth.ErrorRevokedToken) {
+			return nil, status.Error(codes.Unauthenticated, "auth token revoked")
 		}
-		logEvent := log.Debug()
-		if logEvent.Enabled() {
-			token := auth.AuthToken(ctx)
-			log.Debug().Err(err).Msgf("auth: client trusted: %t, token %+v", trusted, token)
+		if errors.Is(err, auth.ErrorExpiredToken) {
+			return nil, status.Error(codes.Unauthenticated, "auth token expired")
 		}
-		return handler(ctx, req)
+		if !trusted {
+			return nil, status.Error(codes.Unauthenticated, "invalid auth token")
+		}
+		return nil, status.Error(codes.InvalidArgument, "unable to parse auth token")
+	}
+	token := auth.AuthToken(authCtx)
+	if !trusted && !a.untrustedRoles[token.Role] {
+		auditRejection(ctx, method, connectionInfo, trusted, fmt.Sprintf("role %s is not allowed for untrusted peers", token.Role))
+		return nil, status.Error(codes.PermissionDenied, "role is not allowed")
+	}
+	logEvent := log.Debug()
+	if logEvent.Enabled() {
+		logEvent.Msgf("auth: client trusted: %t, token %+v", trusted, token)
 	}
+	return authCtx, nil
+}
+
+func (a *authenticator) withToken(ctx context.Context, encodedToken string) (context.Context, error) {
+	if a.verifier == nil {
+		return auth.WithStringToken(ctx, encodedToken)
+	}
+	return auth.WithVerifiedToken(ctx, encodedToken, a.verifier)
+}
+
+// IsTrustedPeer returns true if the auth interceptor found the peer in the trusted peers
+func IsTrustedPeer(ctx context.Context) bool {
+	trusted, _ := ctx.Value(&trustedPeerCtxKey{}).(bool)
+	return trusted
+}
+
+func auditRejection(ctx context.Context, method string, connectionInfo tls.ConnectionInfo, trusted bool, reason string) {
+	zerolog.Ctx(ctx).Warn().
+		Bool("audit", true).
+		Str("method", method).
+		Str("peer", connectionInfo.Peer).
+		Uint64("certificateId", connectionInfo.CertificateId).
+		Bool("trusted", trusted).
+		Str("reason", reason).
+		Msg("auth: token rejected")
 }
 
 func ClientAuth(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 	zerolog.Ctx(ctx).Debug().Msg("ClientAuth")
+	ctx, err := withOutgoingToken(ctx, nil)
+	if err != nil {
+		return err
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// NewClientAuth Client Side Interceptor, see ClientAuth. The tokens which are not forwarded are signed by the
+// signer, the auth package signer when nil
+func NewClientAuth(signer auth.Signer) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		zerolog.Ctx(ctx).Debug().Msg("ClientAuth")
+		ctx, err := withOutgoingToken(ctx, signer)
+		if err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func withOutgoingToken(ctx context.Context, signer auth.Signer) (context.Context, error) {
 	token := auth.AuthToken(ctx)
 	if token.IsAuthenticated() {
-		ctx = metadata.AppendToOutgoingContext(ctx, authTokenMeta, token.WriteToString())
+		// Forward the token as received, services which only verify tokens can not sign them
+		encodedToken := auth.EncodedToken(ctx)
+		if encodedToken == "" {
+			var err error
+			if signer == nil {
+				encodedToken, err = token.WriteToString()
+			} else {
+				encodedToken, err = token.SignToString(signer)
+			}
+			if err != nil {
+				return nil, status.Error(codes.Unauthenticated, fmt.Sprintf("unable to sign auth token: %v", err))
+			}
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, authTokenMeta, encodedToken)
 	}
-	return invoker(ctx, method, req, reply, cc, opts...)
+	return ctx, nil
+}
+
+// NewClientRefreshAuth Client Side Interceptor for clients calling with their own identity, sends the access token
+// of the source refreshing it before it expires. Use it instead of ClientAuth, the refresh call must not go through it
+func NewClientRefreshAuth(source *auth.TokenSource) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, err := withSourceToken(ctx, source)
+		if err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func withSourceToken(ctx context.Context, source *auth.TokenSource) (context.Context, error) {
+	accessToken, err := source.AccessToken(ctx)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("failed to get access token")
+		return nil, status.Error(codes.Unauthenticated, "unable to refresh auth token")
+	}
+	return metadata.AppendToOutgoingContext(ctx, authTokenMeta, accessToken), nil
 }
 
 func ServerTrace(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-	ctx, span := telemetry.StartSpan(ctx, fmt.Sprintf("grpc%s", info.FullMethod))
+	ctx, span := startServerSpan(ctx, info.FullMethod)
 	defer span.End()
+	return handler(ctx, req)
+}
+
+func startServerSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	ctx, span := telemetry.StartSpan(withIncomingTrace(ctx), fmt.Sprintf("grpc%s", method), trace.WithSpanKind(trace.SpanKindServer))
 	contextId := support.ContextId(ctx)
 	if contextId != "" {
 		span.SetAttributes(attribute.String("contextId", contextId))
 	}
-	return handler(ctx, req)
+	return ctx, span
 }
 
 func ClientTrace(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 	zerolog.Ctx(ctx).Debug().Msg("ClientTrace")
-	ctx, span := telemetry.StartSpan(ctx, fmt.Sprintf("grpc%s", method))
+	ctx, span := telemetry.StartSpan(ctx, fmt.Sprintf("grpc%s", method), trace.WithSpanKind(trace.SpanKindClient))
 	defer span.End()
-	return invoker(ctx, method, req, reply, cc, opts...)
+	return invoker(withOutgoingTrace(ctx), method, req, reply, cc, opts...)
 }
 
-func NewServerMetricInterceptor(initCtx context.Context) (grpc.UnaryServerInterceptor, error) {
-	var requestMetric metric.Int64Histogram
-	requestMetric, err := telemetry.Meter.Int64Histogram("grpc", metric.WithUnit("ms"))
-	if err != nil {
-		return nil, err
+func errorCode(err error) codes.Code {
+	if err == nil {
+		return codes.OK
 	}
-	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-		startTime := time.Now()
-		resp, err = handler(ctx, req)
-
-		attributes := make([]attribute.KeyValue, 0)
-		attributes = append(attributes, attribute.String("method", info.FullMethod))
-		var code codes.Code
-		if err == nil {
-			code = codes.OK
-		} else {
-			grpcStatus, ok := status.FromError(err)
-			if ok {
-				code = grpcStatus.Code()
-			} else {
-				code = codes.Internal
-			}
-		}
-		attributes = append(attributes, attribute.String("code", code.String()))
-		requestMetric.Record(ctx, time.Since(startTime).Milliseconds(), metric.WithAttributes(attributes...))
-
-		return
-	}, nil
+	return ToStatus(err).Code()
 }
@@ -0,0 +1,90 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"github.com/iyarkov/kit/auth"
+	"github.com/iyarkov/kit/tls"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestAuthenticate(t *testing.T) {
+	keyring := auth.NewHmacKeyring()
+	if err := keyring.AddKey(1, bytes.Repeat([]byte{1}, 32)); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := keyring.Rotate(1); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	auth.SetSigner(keyring)
+	auth.SetVerifier(keyring)
+	defer auth.SetSigner(nil)
+	defer auth.SetVerifier(nil)
+
+	authenticator, err := newAuthenticator(context.Background(), &auth.Configuration{
+		TrustedPeers:   []string{"gateway"},
+		UntrustedRoles: []string{"user", "guest"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	newToken := func(role auth.Role, expiresIn time.Duration) string {
+		token := auth.Token{AccountId: 4, Role: role, ExpiresAt: time.Now().Add(expiresIn)}
+		encoded, err := token.WriteToString()
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		return encoded
+	}
+
+	type spec struct {
+		name     string
+		peer     string
+		token    string
+		expected codes.Code
+	}
+	suite := []spec{
+		{"trusted admin", "gateway", newToken(auth.Admin, time.Hour), codes.OK},
+		{"trusted expired", "gateway", newToken(auth.Admin, -time.Hour), codes.Unauthenticated},
+		{"untrusted user", "partner", newToken(auth.User, time.Hour), codes.OK},
+		{"untrusted expired", "partner", newToken(auth.User, -time.Hour), codes.Unauthenticated},
+		{"untrusted admin", "partner", newToken(auth.Admin, time.Hour), codes.PermissionDenied},
+		{"untrusted manager", "partner", newToken(auth.Manager, time.Hour), codes.PermissionDenied},
+		{"untrusted forged", "partner", "AQEAAAAAAAAABAAAAAAAAwAAAAAAAGSLJyk=", codes.Unauthenticated},
+		{"untrusted anonymous", "partner", "", codes.OK},
+	}
+	for _, test := range suite {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), &connectionInfoCtxKey{}, tls.ConnectionInfo{Peer: test.peer})
+			if test.token != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(authTokenMeta, test.token))
+			}
+			_, err := authenticator.authenticate(ctx, "/kit.Test/Method")
+			if status.Code(err) != test.expected {
+				t.Errorf("Expecting %s, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestAuthenticatorUnknownRole(t *testing.T) {
+	conf := &auth.Configuration{UntrustedRoles: []string{"user", "usr"}}
+	authenticator, err := newAuthenticator(context.Background(), conf, nil)
+	if err == nil {
+		t.Errorf("Expecting unknown role error")
+	}
+	if len(authenticator.untrustedRoles) != 1 || !authenticator.untrustedRoles[auth.User] {
+		t.Errorf("Expecting the known roles only, got %v", authenticator.untrustedRoles)
+	}
+	if _, _, err = NewServerAuthInterceptors(context.Background(), conf, nil); err == nil {
+		t.Errorf("Expecting unknown role error")
+	}
+	// the compatible constructors ignore the unknown roles
+	if NewServerAuthInterceptor(context.Background(), conf) == nil || NewStreamServerAuthInterceptor(context.Background(), conf) == nil {
+		t.Errorf("Expecting the interceptors")
+	}
+}
@@ -0,0 +1,254 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"github.com/iyarkov/kit/auth"
+	"github.com/iyarkov/kit/telemetry"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limit keys
+const (
+	LimitByPeer    = "peer"
+	LimitByAccount = "account"
+	LimitByGroup   = "group"
+	LimitByMethod  = "method"
+)
+
+const (
+	concurrencyRetryAfter = time.Second
+	limiterSweepInterval  = time.Minute
+)
+
+// Limit token bucket of Rate requests per second with Burst capacity and at most MaxInFlight concurrent requests,
+// tracked separately for each Key value. Zero Rate or MaxInFlight disables the corresponding limit. Anonymous
+// callers share the account 0 and group 0 limits
+type Limit struct {
+	Key         string
+	Rate        float64
+	Burst       int
+	MaxInFlight int
+}
+
+// LimitConfiguration maps full method names to limits, the lookup is the same as in PolicyConfiguration. Limits of
+// the "*" method are shared by all the methods without their own limits
+type LimitConfiguration struct {
+	Methods map[string][]Limit
+}
+
+type limiterEntry struct {
+	bucket   *rate.Limiter
+	inFlight int
+	lastSeen time.Time
+}
+
+// limiter state of a configured Limit
+type limiter struct {
+	limit     Limit
+	idle      time.Duration
+	mu        sync.Mutex
+	entries   map[string]*limiterEntry
+	lastSweep time.Time
+}
+
+func newLimiter(method string, limit Limit) (*limiter, error) {
+	switch limit.Key {
+	case LimitByPeer, LimitByAccount, LimitByGroup, LimitByMethod:
+	default:
+		return nil, fmt.Errorf("limit %s: unknown key %s", method, limit.Key)
+	}
+	if limit.Rate < 0 || limit.Burst < 0 || limit.MaxInFlight < 0 {
+		return nil, fmt.Errorf("limit %s: negative limit", method)
+	}
+	if limit.Rate > 0 && limit.Burst == 0 {
+		limit.Burst = int(math.Ceil(limit.Rate))
+	}
+	idle := limiterSweepInterval
+	if limit.Rate > 0 {
+		// an idle bucket is full again, it is safe to forget it
+		if refill := time.Duration(float64(limit.Burst) / limit.Rate * float64(time.Second)); refill > idle {
+			idle = refill
+		}
+	}
+	return &limiter{
+		limit:     limit,
+		idle:      idle,
+		entries:   make(map[string]*limiterEntry),
+		lastSweep: time.Now(),
+	}, nil
+}
+
+// acquire takes a token and an in-flight slot, returns the taken token so it can be refunded, the rejection reason and
+// the delay before the caller may retry
+func (l *limiter) acquire(key string, now time.Time) (token *rate.Reservation, reason string, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	entry, ok := l.entries[key]
+	if !ok {
+		entry = &limiterEntry{}
+		if l.limit.Rate > 0 {
+			entry.bucket = rate.NewLimiter(rate.Limit(l.limit.Rate), l.limit.Burst)
+		}
+		l.entries[key] = entry
+	}
+	entry.lastSeen = now
+	if l.limit.MaxInFlight > 0 && entry.inFlight >= l.limit.MaxInFlight {
+		return nil, "concurrency", concurrencyRetryAfter
+	}
+	if entry.bucket != nil {
+		token = entry.bucket.ReserveN(now, 1)
+		if delay := token.DelayFrom(now); delay > 0 {
+			token.CancelAt(now)
+			return nil, "rate", delay
+		}
+	}
+	entry.inFlight++
+	return token, "", 0
+}
+
+func (l *limiter) release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if entry, ok := l.entries[key]; ok {
+		entry.inFlight--
+	}
+}
+
+// refund returns the token and the in-flight slot of a request rejected by another limiter
+func (l *limiter) refund(key string, token *rate.Reservation, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if token != nil {
+		token.CancelAt(now)
+	}
+	if entry, ok := l.entries[key]; ok {
+		entry.inFlight--
+	}
+}
+
+func (l *limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < limiterSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, entry := range l.entries {
+		if entry.inFlight == 0 && now.Sub(entry.lastSeen) > l.idle {
+			delete(l.entries, key)
+		}
+	}
+}
+
+func limitKey(ctx context.Context, key string, fullMethod string) string {
+	switch key {
+	case LimitByPeer:
+		return ConnectionInfo(ctx).Peer
+	case LimitByAccount:
+		return strconv.FormatUint(auth.AuthToken(ctx).AccountId, 10)
+	case LimitByGroup:
+		return strconv.FormatUint(uint64(auth.AuthToken(ctx).GroupId), 10)
+	default:
+		return fullMethod
+	}
+}
+
+type limitInterceptor struct {
+	limiters       map[string][]*limiter
+	rejectedMetric metric.Int64Counter
+}
+
+func newLimitInterceptor(conf *LimitConfiguration) (*limitInterceptor, error) {
+	rejectedMetric, err := telemetry.Meter.Int64Counter("grpc.limit.rejected")
+	if err != nil {
+		return nil, err
+	}
+	interceptor := limitInterceptor{
+		limiters:       make(map[string][]*limiter, len(conf.Methods)),
+		rejectedMetric: rejectedMetric,
+	}
+	for method, limits := range conf.Methods {
+		for _, limit := range limits {
+			resolved, err := newLimiter(method, limit)
+			if err != nil {
+				return nil, err
+			}
+			interceptor.limiters[method] = append(interceptor.limiters[method], resolved)
+		}
+	}
+	return &interceptor, nil
+}
+
+// acquire checks all the method limits, the returned release func must be called when the request is done
+func (i *limitInterceptor) acquire(ctx context.Context, fullMethod string) (func(), error) {
+	limiters, _ := findMethod(i.limiters, fullMethod)
+	now := time.Now()
+	keys := make([]string, 0, len(limiters))
+	tokens := make([]*rate.Reservation, 0, len(limiters))
+	for _, limiter := range limiters {
+		key := limitKey(ctx, limiter.limit.Key, fullMethod)
+		token, reason, retryAfter := limiter.acquire(key, now)
+		if reason != "" {
+			for idx, acquired := range keys {
+				limiters[idx].refund(acquired, tokens[idx], now)
+			}
+			// expected under load and counted by the metric
+			zerolog.Ctx(ctx).Debug().Msgf("limit: %s %s limit exceeded for %s %s", fullMethod, reason, limiter.limit.Key, key)
+			i.rejectedMetric.Add(ctx, 1, metric.WithAttributes(
+				attribute.String("method", fullMethod),
+				attribute.String("key", limiter.limit.Key),
+				attribute.String("reason", reason),
+			))
+			return nil, withDetails(codes.ResourceExhausted, fmt.Sprintf("%s limit exceeded", reason), &errdetails.RetryInfo{
+				RetryDelay: durationpb.New(retryAfter),
+			}).Err()
+		}
+		keys = append(keys, key)
+		tokens = append(tokens, token)
+	}
+	return func() {
+		for idx, key := range keys {
+			limiters[idx].release(key)
+		}
+	}, nil
+}
+
+// NewServerLimitInterceptors Server Side unary and stream Interceptors sharing the limits, reject requests over the
+// method limits with ResourceExhausted and RetryInfo. The stream in-flight slot is held until the stream is finished.
+// Install them after the auth interceptors to limit by account or group
+func NewServerLimitInterceptors(initCtx context.Context, conf *LimitConfiguration) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor, error) {
+	interceptor, err := newLimitInterceptor(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	zerolog.Ctx(initCtx).Debug().Msgf("limit interceptors initialized with %d methods", len(conf.Methods))
+	unary := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		release, err := interceptor.acquire(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+	stream := func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		release, err := interceptor.acquire(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		defer release()
+		return handler(srv, stream)
+	}
+	return unary, stream, nil
+}
@@ -0,0 +1,122 @@
+package grpc
+
+import (
+	"context"
+	"github.com/iyarkov/kit/auth"
+	"github.com/iyarkov/kit/telemetry"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestServerLimit(t *testing.T) {
+	telemetry.InitTelemetry(context.Background(), &telemetry.Configuration{})
+	unary, _, err := NewServerLimitInterceptors(context.Background(), &LimitConfiguration{
+		Methods: map[string][]Limit{
+			"*":              {{Key: LimitByAccount, Rate: 1, Burst: 2}},
+			"/kit.Test/Slow": {{Key: LimitByMethod, MaxInFlight: 1}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	withAccount := func(accountId uint64) context.Context {
+		return auth.WithToken(context.Background(), &auth.Token{AccountId: accountId, Role: auth.User, ExpiresAt: time.Now().Add(time.Hour)})
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/kit.Test/Get"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err = unary(withAccount(1), nil, info, handler); err != nil {
+			t.Fatalf("Burst request %d must pass, got %v", i, err)
+		}
+	}
+	_, err = unary(withAccount(1), nil, info, handler)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expecting ResourceExhausted, got %v", err)
+	}
+	var retryInfo *errdetails.RetryInfo
+	for _, detail := range status.Convert(err).Details() {
+		if typed, ok := detail.(*errdetails.RetryInfo); ok {
+			retryInfo = typed
+		}
+	}
+	if retryInfo == nil || retryInfo.RetryDelay.AsDuration() <= 0 {
+		t.Errorf("Expecting retry info, got %v", retryInfo)
+	}
+	if _, err = unary(withAccount(2), nil, info, handler); err != nil {
+		t.Errorf("Other accounts must not be limited, got %v", err)
+	}
+
+	slowInfo := &grpc.UnaryServerInfo{FullMethod: "/kit.Test/Slow"}
+	started := make(chan bool)
+	finish := make(chan bool)
+	go func() {
+		_, _ = unary(withAccount(3), nil, slowInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+			close(started)
+			<-finish
+			return "ok", nil
+		})
+	}()
+	<-started
+	if _, err = unary(withAccount(4), nil, slowInfo, handler); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expecting concurrency limit, got %v", err)
+	}
+	close(finish)
+	time.Sleep(10 * time.Millisecond)
+	if _, err = unary(withAccount(4), nil, slowInfo, handler); err != nil {
+		t.Errorf("Slot must be released, got %v", err)
+	}
+}
+
+func TestServerLimitRefund(t *testing.T) {
+	telemetry.InitTelemetry(context.Background(), &telemetry.Configuration{})
+	unary, _, err := NewServerLimitInterceptors(context.Background(), &LimitConfiguration{
+		Methods: map[string][]Limit{
+			"/kit.Test/Slow": {{Key: LimitByAccount, Rate: 0.1, Burst: 2}, {Key: LimitByMethod, MaxInFlight: 1}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	ctx := auth.WithToken(context.Background(), &auth.Token{AccountId: 1, Role: auth.User, ExpiresAt: time.Now().Add(time.Hour)})
+	info := &grpc.UnaryServerInfo{FullMethod: "/kit.Test/Slow"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	started := make(chan bool)
+	finish := make(chan bool)
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		_, _ = unary(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			close(started)
+			<-finish
+			return "ok", nil
+		})
+	}()
+	<-started
+	if _, err = unary(ctx, nil, info, handler); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expecting concurrency limit, got %v", err)
+	}
+	close(finish)
+	<-done
+	if _, err = unary(ctx, nil, info, handler); err != nil {
+		t.Errorf("Expecting the account token of the rejected request to be refunded, got %v", err)
+	}
+}
+
+func TestServerLimitConfiguration(t *testing.T) {
+	_, _, err := NewServerLimitInterceptors(context.Background(), &LimitConfiguration{
+		Methods: map[string][]Limit{"*": {{Key: "ip", Rate: 1}}},
+	})
+	if err == nil {
+		t.Errorf("Expecting unknown key error")
+	}
+}
@@ -0,0 +1,319 @@
+package grpc
+
+import (
+	"context"
+	"github.com/iyarkov/kit/telemetry"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.18.0"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricConfiguration PeerAttribute adds the peer name (the client certificate common name on the server side, the
+// target on the client side) to the metric attributes, mind the cardinality
+type MetricConfiguration struct {
+	PeerAttribute bool
+}
+
+// rpcMetrics OpenTelemetry RPC semantic conventions metrics of the server or the client side. The server side also
+// records the grpc histogram of the earlier versions, the duration in milliseconds with the method and code attributes
+type rpcMetrics struct {
+	peerAttribute   bool
+	legacyDuration  metric.Int64Histogram
+	duration        metric.Float64Histogram
+	requestSize     metric.Int64Histogram
+	responseSize    metric.Int64Histogram
+	requestsPerRpc  metric.Int64Histogram
+	responsesPerRpc metric.Int64Histogram
+	active          metric.Int64UpDownCounter
+}
+
+func newRpcMetrics(side string, conf *MetricConfiguration) (*rpcMetrics, error) {
+	if conf == nil {
+		conf = &MetricConfiguration{}
+	}
+	prefix := "rpc." + side + "."
+	metrics := rpcMetrics{
+		peerAttribute: conf.PeerAttribute,
+	}
+	var err error
+	if side == "server" {
+		if metrics.legacyDuration, err = telemetry.Meter.Int64Histogram("grpc", metric.WithUnit("ms")); err != nil {
+			return nil, err
+		}
+	}
+	if metrics.duration, err = telemetry.Meter.Float64Histogram(prefix+"duration", metric.WithUnit("ms")); err != nil {
+		return nil, err
+	}
+	if metrics.requestSize, err = telemetry.Meter.Int64Histogram(prefix+"request.size", metric.WithUnit("By")); err != nil {
+		return nil, err
+	}
+	if metrics.responseSize, err = telemetry.Meter.Int64Histogram(prefix+"response.size", metric.WithUnit("By")); err != nil {
+		return nil, err
+	}
+	if metrics.requestsPerRpc, err = telemetry.Meter.Int64Histogram(prefix+"requests_per_rpc", metric.WithUnit("{count}")); err != nil {
+		return nil, err
+	}
+	if metrics.responsesPerRpc, err = telemetry.Meter.Int64Histogram(prefix+"responses_per_rpc", metric.WithUnit("{count}")); err != nil {
+		return nil, err
+	}
+	if metrics.active, err = telemetry.Meter.Int64UpDownCounter(prefix+"active_requests", metric.WithUnit("{request}")); err != nil {
+		return nil, err
+	}
+	return &metrics, nil
+}
+
+func (m *rpcMetrics) attributes(fullMethod string, peer string) []attribute.KeyValue {
+	service, method := splitMethod(fullMethod)
+	attributes := []attribute.KeyValue{semconv.RPCSystemGRPC, semconv.RPCService(service), semconv.RPCMethod(method)}
+	if m.peerAttribute {
+		attributes = append(attributes, semconv.NetPeerName(peer))
+	}
+	return attributes
+}
+
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if idx := strings.LastIndexByte(fullMethod, '/'); idx >= 0 {
+		return fullMethod[:idx], fullMethod[idx+1:]
+	}
+	return "", fullMethod
+}
+
+func messageSize(msg interface{}) (int64, bool) {
+	if message, ok := msg.(proto.Message); ok {
+		return int64(proto.Size(message)), true
+	}
+	return 0, false
+}
+
+// rpcRecorder records the metrics of a single call
+type rpcRecorder struct {
+	metrics    *rpcMetrics
+	ctx        context.Context
+	fullMethod string
+	attributes []attribute.KeyValue
+	startTime  time.Time
+	once       sync.Once
+	done       chan struct{}
+
+	mu        sync.Mutex
+	requests  int64
+	responses int64
+}
+
+func (m *rpcMetrics) start(ctx context.Context, fullMethod string, peer string) *rpcRecorder {
+	recorder := rpcRecorder{
+		metrics:    m,
+		ctx:        ctx,
+		fullMethod: fullMethod,
+		attributes: m.attributes(fullMethod, peer),
+		startTime:  time.Now(),
+		done:       make(chan struct{}),
+	}
+	m.active.Add(ctx, 1, metric.WithAttributes(recorder.attributes...))
+	return &recorder
+}
+
+func (r *rpcRecorder) request(msg interface{}) {
+	r.mu.Lock()
+	r.requests++
+	r.mu.Unlock()
+	if size, ok := messageSize(msg); ok {
+		r.metrics.requestSize.Record(r.ctx, size, metric.WithAttributes(r.attributes...))
+	}
+}
+
+func (r *rpcRecorder) response(msg interface{}) {
+	r.mu.Lock()
+	r.responses++
+	r.mu.Unlock()
+	if size, ok := messageSize(msg); ok {
+		r.metrics.responseSize.Record(r.ctx, size, metric.WithAttributes(r.attributes...))
+	}
+}
+
+// finish records the call duration, the messages per stream are recorded for streaming calls only
+func (r *rpcRecorder) finish(err error, streaming bool) {
+	r.once.Do(func() {
+		close(r.done)
+		elapsed := time.Since(r.startTime)
+		r.metrics.active.Add(r.ctx, -1, metric.WithAttributes(r.attributes...))
+		code := errorCode(err)
+		withCode := metric.WithAttributes(append(append([]attribute.KeyValue{}, r.attributes...), semconv.RPCGRPCStatusCodeKey.Int(int(code)))...)
+		r.metrics.duration.Record(r.ctx, float64(elapsed)/float64(time.Millisecond), withCode)
+		if r.metrics.legacyDuration != nil {
+			r.metrics.legacyDuration.Record(r.ctx, elapsed.Milliseconds(), metric.WithAttributes(
+				attribute.String("method", r.fullMethod),
+				attribute.String("code", code.String()),
+			))
+		}
+		if streaming {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.metrics.requestsPerRpc.Record(r.ctx, r.requests, withCode)
+			r.metrics.responsesPerRpc.Record(r.ctx, r.responses, withCode)
+		}
+	})
+}
+
+// NewServerMetricInterceptor Server Side Interceptor, records the rpc.server.* metrics and the grpc histogram with the
+// default configuration, see NewServerMetricInterceptors
+func NewServerMetricInterceptor(initCtx context.Context) (grpc.UnaryServerInterceptor, error) {
+	unary, _, err := NewServerMetricInterceptors(initCtx, nil)
+	return unary, err
+}
+
+// NewServerMetricInterceptors Server Side unary and stream Interceptors sharing the instruments, the default
+// configuration when conf is nil. The stream duration covers the whole stream
+func NewServerMetricInterceptors(initCtx context.Context, conf *MetricConfiguration) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor, error) {
+	metrics, err := newRpcMetrics("server", conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	zerolog.Ctx(initCtx).Debug().Msg("metric interceptors initialized")
+	unary := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		recorder := metrics.start(ctx, info.FullMethod, ConnectionInfo(ctx).Peer)
+		recorder.request(req)
+		resp, err := handler(ctx, req)
+		if err == nil {
+			recorder.response(resp)
+		}
+		recorder.finish(err, false)
+		return resp, err
+	}
+	stream := func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := stream.Context()
+		recorder := metrics.start(ctx, info.FullMethod, ConnectionInfo(ctx).Peer)
+		err := handler(srv, &meteredServerStream{
+			ServerStream: stream,
+			recorder:     recorder,
+		})
+		recorder.finish(err, true)
+		return err
+	}
+	return unary, stream, nil
+}
+
+// meteredServerStream records the stream messages
+type meteredServerStream struct {
+	grpc.ServerStream
+	recorder *rpcRecorder
+}
+
+func (s *meteredServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.recorder.request(m)
+	}
+	return err
+}
+
+func (s *meteredServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.recorder.response(m)
+	}
+	return err
+}
+
+// NewStreamServerMetricInterceptor Server Side Stream Interceptor, see NewServerMetricInterceptor
+func NewStreamServerMetricInterceptor(initCtx context.Context) (grpc.StreamServerInterceptor, error) {
+	_, stream, err := NewServerMetricInterceptors(initCtx, nil)
+	return stream, err
+}
+
+// NewClientMetricInterceptor Client Side Interceptor, records the rpc.client.* metrics. The default configuration
+// when conf is nil
+func NewClientMetricInterceptor(initCtx context.Context, conf *MetricConfiguration) (grpc.UnaryClientInterceptor, error) {
+	metrics, err := newRpcMetrics("client", conf)
+	if err != nil {
+		return nil, err
+	}
+	zerolog.Ctx(initCtx).Debug().Msg("client metric interceptor initialized")
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		recorder := metrics.start(ctx, method, clientTarget(cc))
+		recorder.request(req)
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil {
+			recorder.response(reply)
+		}
+		recorder.finish(err, false)
+		return err
+	}, nil
+}
+
+func clientTarget(cc *grpc.ClientConn) string {
+	if cc == nil {
+		return ""
+	}
+	return cc.Target()
+}
+
+// meteredClientStream records the stream messages, the stream is finished when RecvMsg fails or the single response
+// of a not server streaming call is received
+type meteredClientStream struct {
+	grpc.ClientStream
+	recorder      *rpcRecorder
+	serverStreams bool
+}
+
+func (s *meteredClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.recorder.request(m)
+	}
+	return err
+}
+
+func (s *meteredClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == nil:
+		s.recorder.response(m)
+		if !s.serverStreams {
+			s.recorder.finish(nil, true)
+		}
+	case err == io.EOF:
+		s.recorder.finish(nil, true)
+	default:
+		s.recorder.finish(err, true)
+	}
+	return err
+}
+
+// NewStreamClientMetricInterceptor Client Side Stream Interceptor, see NewClientMetricInterceptor. The stream is
+// recorded when RecvMsg fails, the single response of a client streaming call is received or the context is done
+func NewStreamClientMetricInterceptor(initCtx context.Context, conf *MetricConfiguration) (grpc.StreamClientInterceptor, error) {
+	metrics, err := newRpcMetrics("client", conf)
+	if err != nil {
+		return nil, err
+	}
+	zerolog.Ctx(initCtx).Debug().Msg("stream client metric interceptor initialized")
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		recorder := metrics.start(ctx, method, clientTarget(cc))
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			recorder.finish(err, true)
+			return nil, err
+		}
+		go func() {
+			select {
+			case <-ctx.Done():
+				recorder.finish(ctx.Err(), true)
+			case <-recorder.done:
+			}
+		}()
+		return &meteredClientStream{
+			ClientStream:  stream,
+			recorder:      recorder,
+			serverStreams: desc.ServerStreams,
+		}, nil
+	}, nil
+}
@@ -0,0 +1,137 @@
+package grpc
+
+import (
+	"context"
+	"github.com/iyarkov/kit/telemetry"
+	sdk "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"testing"
+)
+
+func collectMetrics(t *testing.T, reader sdk.Reader) map[string]metricdata.Aggregation {
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &data); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	result := make(map[string]metricdata.Aggregation)
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			result[m.Name] = m.Data
+		}
+	}
+	return result
+}
+
+func TestServerMetric(t *testing.T) {
+	reader := sdk.NewManualReader()
+	telemetry.Meter = sdk.NewMeterProvider(sdk.WithReader(reader)).Meter("test")
+	ctx := context.Background()
+
+	unary, err := NewServerMetricInterceptor(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	_, err = unary(ctx, wrapperspb.String("hello"), &grpc.UnaryServerInfo{FullMethod: "/kit.Test/Get"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return wrapperspb.String("hello world"), nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	stream, err := NewStreamServerMetricInterceptor(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	err = stream(nil, &testServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/kit.Test/Stream"}, func(srv interface{}, stream grpc.ServerStream) error {
+		for i := 0; i < 3; i++ {
+			if err := stream.RecvMsg(wrapperspb.String("")); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	metrics := collectMetrics(t, reader)
+	duration, ok := metrics["rpc.server.duration"].(metricdata.Histogram[float64])
+	if !ok || len(duration.DataPoints) != 2 {
+		t.Errorf("Expecting duration of 2 methods, got %v", metrics["rpc.server.duration"])
+	}
+	legacy, ok := metrics["grpc"].(metricdata.Histogram[int64])
+	if !ok || len(legacy.DataPoints) != 2 {
+		t.Errorf("Expecting the grpc histogram of 2 methods, got %v", metrics["grpc"])
+	}
+	for _, point := range legacy.DataPoints {
+		method, _ := point.Attributes.Value("method")
+		code, _ := point.Attributes.Value("code")
+		if method.AsString() != "/kit.Test/Get" && method.AsString() != "/kit.Test/Stream" || code.AsString() != "OK" {
+			t.Errorf("Unexpected grpc histogram attributes %v", point.Attributes)
+		}
+	}
+	responseSize, ok := metrics["rpc.server.response.size"].(metricdata.Histogram[int64])
+	if !ok || len(responseSize.DataPoints) != 1 || responseSize.DataPoints[0].Sum != 13 {
+		t.Errorf("Expecting response size 13, got %v", metrics["rpc.server.response.size"])
+	}
+	requests, ok := metrics["rpc.server.requests_per_rpc"].(metricdata.Histogram[int64])
+	if !ok || len(requests.DataPoints) != 1 || requests.DataPoints[0].Sum != 3 {
+		t.Errorf("Expecting 3 stream requests, got %v", metrics["rpc.server.requests_per_rpc"])
+	}
+	active, ok := metrics["rpc.server.active_requests"].(metricdata.Sum[int64])
+	if !ok {
+		t.Fatalf("Expecting active requests, got %v", metrics["rpc.server.active_requests"])
+	}
+	for _, point := range active.DataPoints {
+		if point.Value != 0 {
+			t.Errorf("Expecting no active requests, got %d", point.Value)
+		}
+	}
+}
+
+func TestClientStreamMetric(t *testing.T) {
+	reader := sdk.NewManualReader()
+	telemetry.Meter = sdk.NewMeterProvider(sdk.WithReader(reader)).Meter("test")
+	ctx := context.Background()
+
+	interceptor, err := NewStreamClientMetricInterceptor(ctx, &MetricConfiguration{})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	desc := &grpc.StreamDesc{StreamName: "Upload", ClientStreams: true}
+	stream, err := interceptor(ctx, desc, nil, "/kit.Test/Upload", func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return &testClientStream{responses: 1}, nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err = stream.SendMsg(wrapperspb.String("chunk")); err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+	}
+	if err = stream.RecvMsg(wrapperspb.String("")); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	metrics := collectMetrics(t, reader)
+	duration, ok := metrics["rpc.client.duration"].(metricdata.Histogram[float64])
+	if !ok || len(duration.DataPoints) != 1 || duration.DataPoints[0].Count != 1 {
+		t.Errorf("Expecting the duration to be recorded, got %v", metrics["rpc.client.duration"])
+	}
+	requests, ok := metrics["rpc.client.requests_per_rpc"].(metricdata.Histogram[int64])
+	if !ok || len(requests.DataPoints) != 1 || requests.DataPoints[0].Sum != 3 {
+		t.Errorf("Expecting 3 stream requests, got %v", metrics["rpc.client.requests_per_rpc"])
+	}
+	active, ok := metrics["rpc.client.active_requests"].(metricdata.Sum[int64])
+	if !ok {
+		t.Fatalf("Expecting active requests, got %v", metrics["rpc.client.active_requests"])
+	}
+	for _, point := range active.DataPoints {
+		if point.Value != 0 {
+			t.Errorf("Expecting no active requests, got %d", point.Value)
+		}
+	}
+}
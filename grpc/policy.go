@@ -0,0 +1,136 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"github.com/iyarkov/kit/auth"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// Policy authorization policy of a gRPC method. The caller must have any of the Roles, or any of the TrustedRoles
+// when calling from a trusted peer, and all the Permissions. Policy without roles and permissions only requires the
+// caller to be authenticated
+type Policy struct {
+	Anonymous    bool
+	Roles        []string
+	TrustedRoles []string
+	Permissions  []auth.Permission
+}
+
+// PolicyConfiguration maps full method names to policies. Service level default is "/package.Service/*",
+// "*" is the default for all methods. Methods without a policy are denied
+type PolicyConfiguration struct {
+	Methods map[string]Policy
+}
+
+type resolvedPolicy struct {
+	anonymous    bool
+	roles        []auth.Role
+	trustedRoles []auth.Role
+	permissions  []auth.Permission
+}
+
+func NewServerPolicyInterceptor(initCtx context.Context, conf *PolicyConfiguration) (grpc.UnaryServerInterceptor, error) {
+	policies, err := resolvePolicies(conf)
+	if err != nil {
+		return nil, err
+	}
+	zerolog.Ctx(initCtx).Debug().Msgf("policy interceptor initialized with %d policies", len(policies))
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		if err = authorize(ctx, policies, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}, nil
+}
+
+func resolvePolicies(conf *PolicyConfiguration) (map[string]resolvedPolicy, error) {
+	policies := make(map[string]resolvedPolicy, len(conf.Methods))
+	for method, policy := range conf.Methods {
+		roles, err := resolveRoles(method, policy.Roles)
+		if err != nil {
+			return nil, err
+		}
+		trustedRoles, err := resolveRoles(method, policy.TrustedRoles)
+		if err != nil {
+			return nil, err
+		}
+		policies[method] = resolvedPolicy{
+			anonymous:    policy.Anonymous,
+			roles:        roles,
+			trustedRoles: trustedRoles,
+			permissions:  policy.Permissions,
+		}
+	}
+	return policies, nil
+}
+
+func resolveRoles(method string, names []string) ([]auth.Role, error) {
+	roles := make([]auth.Role, 0, len(names))
+	for _, name := range names {
+		role, ok := auth.RoleByName(name)
+		if !ok {
+			return nil, fmt.Errorf("policy %s: unknown role %s", method, name)
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+func hasAnyRole(token *auth.Token, roles []auth.Role) bool {
+	for _, role := range roles {
+		if token.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// findMethod looks up the method value: exact method name, then "/package.Service/*", then "*"
+func findMethod[T any](methods map[string]T, fullMethod string) (T, bool) {
+	if value, ok := methods[fullMethod]; ok {
+		return value, true
+	}
+	if idx := strings.LastIndexByte(fullMethod, '/'); idx > 0 {
+		if value, ok := methods[fullMethod[:idx+1]+"*"]; ok {
+			return value, true
+		}
+	}
+	value, ok := methods["*"]
+	return value, ok
+}
+
+func authorize(ctx context.Context, policies map[string]resolvedPolicy, fullMethod string) error {
+	log := zerolog.Ctx(ctx)
+	policy, found := findMethod(policies, fullMethod)
+	if !found {
+		log.Warn().Msgf("policy: no policy for %s, access denied", fullMethod)
+		return status.Error(codes.PermissionDenied, "access denied")
+	}
+	if policy.anonymous {
+		return nil
+	}
+	token := auth.AuthToken(ctx)
+	if !token.IsAuthenticated() {
+		log.Debug().Msgf("policy: %s requires authentication", fullMethod)
+		return status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if len(policy.roles) > 0 || len(policy.trustedRoles) > 0 {
+		allowed := hasAnyRole(&token, policy.roles) || (IsTrustedPeer(ctx) && hasAnyRole(&token, policy.trustedRoles))
+		if !allowed {
+			log.Debug().Msgf("policy: role %s is not allowed to call %s", token.Role, fullMethod)
+			return status.Error(codes.PermissionDenied, "access denied")
+		}
+	}
+	for _, permission := range policy.permissions {
+		if !token.Can(permission) {
+			log.Debug().Msgf("policy: role %s does not have permission %s to call %s", token.Role, permission, fullMethod)
+			return status.Error(codes.PermissionDenied, "access denied")
+		}
+	}
+	return nil
+}
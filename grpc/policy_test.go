@@ -0,0 +1,122 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/iyarkov/kit/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+var testPolicies = `{
+	"Methods": {
+		"/kit.Orders/List": {"Anonymous": true},
+		"/kit.Orders/Delete": {"Roles": ["admin"]},
+		"/kit.Orders/*": {"Roles": ["user", "operator"]},
+		"/kit.Reports/Export": {"Permissions": ["test.reports.export"]},
+		"/kit.Profile/Get": {}
+	}
+}`
+
+func TestPolicy(t *testing.T) {
+	if err := auth.Grant(auth.Manager, "test.reports.export"); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	var conf PolicyConfiguration
+	if err := json.Unmarshal([]byte(testPolicies), &conf); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	policies, err := resolvePolicies(&conf)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	type spec struct {
+		method   string
+		role     auth.Role
+		expected codes.Code
+	}
+	suite := []spec{
+		{"/kit.Orders/List", auth.Anonymous, codes.OK},
+		{"/kit.Orders/Delete", auth.Admin, codes.OK},
+		{"/kit.Orders/Delete", auth.Manager, codes.PermissionDenied},
+		{"/kit.Orders/Create", auth.Manager, codes.OK},
+		{"/kit.Orders/Create", auth.Operator, codes.OK},
+		{"/kit.Orders/Create", auth.Guest, codes.PermissionDenied},
+		{"/kit.Orders/Create", auth.Anonymous, codes.Unauthenticated},
+		{"/kit.Reports/Export", auth.Admin, codes.OK},
+		{"/kit.Reports/Export", auth.User, codes.PermissionDenied},
+		{"/kit.Reports/Import", auth.Admin, codes.PermissionDenied},
+		{"/kit.Profile/Get", auth.Guest, codes.OK},
+		{"/kit.Profile/Get", auth.Anonymous, codes.Unauthenticated},
+		{"/kit.Unknown/Get", auth.Admin, codes.PermissionDenied},
+	}
+	for _, test := range suite {
+		t.Run(test.method+"-"+test.role.String(), func(t *testing.T) {
+			ctx := auth.WithToken(context.Background(), &auth.Token{Role: test.role})
+			err := authorize(ctx, policies, test.method)
+			if status.Code(err) != test.expected {
+				t.Errorf("Expecting %s, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestPolicyDefault(t *testing.T) {
+	policies, err := resolvePolicies(&PolicyConfiguration{
+		Methods: map[string]Policy{
+			"*": {Roles: []string{"admin"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	ctx := auth.WithToken(context.Background(), &auth.Token{Role: auth.Admin})
+	if err = authorize(ctx, policies, "/kit.Any/Method"); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestPolicyUnknownRole(t *testing.T) {
+	_, err := resolvePolicies(&PolicyConfiguration{
+		Methods: map[string]Policy{
+			"*": {Roles: []string{"superuser"}},
+		},
+	})
+	if err == nil {
+		t.Errorf("Unknown role must be rejected")
+	}
+}
+
+func TestPolicyTrustedRoles(t *testing.T) {
+	policies, err := resolvePolicies(&PolicyConfiguration{
+		Methods: map[string]Policy{
+			"/iyarkov.kit.Admin/*": adminPolicy,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	type spec struct {
+		role     auth.Role
+		trusted  bool
+		expected codes.Code
+	}
+	suite := []spec{
+		{auth.Admin, false, codes.OK},
+		{auth.Admin, true, codes.OK},
+		{auth.Operator, true, codes.OK},
+		{auth.Operator, false, codes.PermissionDenied},
+		{auth.Manager, true, codes.PermissionDenied},
+	}
+	for _, test := range suite {
+		ctx := auth.WithToken(context.Background(), &auth.Token{Role: test.role})
+		ctx = context.WithValue(ctx, &trustedPeerCtxKey{}, test.trusted)
+		err := authorize(ctx, policies, "/iyarkov.kit.Admin/GetConfig")
+		if status.Code(err) != test.expected {
+			t.Errorf("%s trusted %t: expecting %s, got %v", test.role, test.trusted, test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package grpc
+
+import (
+	"context"
+	"github.com/iyarkov/kit/telemetry"
+	"google.golang.org/grpc/metadata"
+)
+
+// metadataCarrier propagation.TextMapCarrier over gRPC metadata, the metadata keys are lower case
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// withIncomingTrace continues the caller trace, traceparent, tracestate and baggage are read from the metadata
+func withIncomingTrace(ctx context.Context) context.Context {
+	meta, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return telemetry.Extract(ctx, metadataCarrier(meta))
+}
+
+// withOutgoingTrace passes the current span to the server, previously injected values are replaced
+func withOutgoingTrace(ctx context.Context) context.Context {
+	meta, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		meta = meta.Copy()
+	} else {
+		meta = metadata.MD{}
+	}
+	telemetry.Inject(ctx, metadataCarrier(meta))
+	return metadata.NewOutgoingContext(ctx, meta)
+}
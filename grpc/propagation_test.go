@@ -0,0 +1,47 @@
+package grpc
+
+import (
+	"context"
+	"github.com/iyarkov/kit/telemetry"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"strings"
+	"testing"
+)
+
+func TestTracePropagation(t *testing.T) {
+	telemetry.InitTelemetry(context.Background(), &telemetry.Configuration{})
+	traceId := "4bf92f3577b34da6a3ce929d0e0e4736"
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"traceparent", "00-"+traceId+"-00f067aa0ba902b7-01",
+		"baggage", "tenant=acme",
+	))
+
+	var outgoing metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		outgoing, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		if trace.SpanContextFromContext(ctx).TraceID().String() != traceId {
+			t.Errorf("Server span must continue the caller trace, got %s", trace.SpanContextFromContext(ctx).TraceID())
+		}
+		if baggage.FromContext(ctx).Member("tenant").Value() != "acme" {
+			t.Errorf("Expecting baggage, got %s", baggage.FromContext(ctx))
+		}
+		return nil, ClientTrace(ctx, "/kit.Test/Next", nil, nil, nil, invoker)
+	}
+	if _, err := ServerTrace(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/kit.Test/Get"}, handler); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	traceparent := outgoing.Get("traceparent")
+	if len(traceparent) != 1 || !strings.Contains(traceparent[0], traceId) {
+		t.Errorf("Client must pass the trace to the next service, got %v", traceparent)
+	}
+	if len(outgoing.Get("baggage")) != 1 || outgoing.Get("baggage")[0] != "tenant=acme" {
+		t.Errorf("Client must pass the baggage to the next service, got %v", outgoing.Get("baggage"))
+	}
+}
@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"github.com/iyarkov/kit/support"
+	"github.com/iyarkov/kit/telemetry"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"runtime/debug"
+)
+
+// NewServerRecoveryInterceptor Server Side Interceptor, converts handler panics into Internal errors
+func NewServerRecoveryInterceptor(initCtx context.Context) (grpc.UnaryServerInterceptor, error) {
+	panicsMetric, err := telemetry.Meter.Int64Counter("panics")
+	if err != nil {
+		return nil, err
+	}
+	zerolog.Ctx(initCtx).Debug().Msg("recovery interceptor initialized")
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ctx, panicsMetric, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}, nil
+}
+
+// NewStreamServerRecoveryInterceptor Server Side Stream Interceptor, see NewServerRecoveryInterceptor
+func NewStreamServerRecoveryInterceptor(initCtx context.Context) (grpc.StreamServerInterceptor, error) {
+	panicsMetric, err := telemetry.Meter.Int64Counter("panics")
+	if err != nil {
+		return nil, err
+	}
+	zerolog.Ctx(initCtx).Debug().Msg("stream recovery interceptor initialized")
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(stream.Context(), panicsMetric, info.FullMethod, r)
+			}
+		}()
+		return handler(srv, stream)
+	}, nil
+}
+
+func recovered(ctx context.Context, panicsMetric metric.Int64Counter, method string, r interface{}) error {
+	zerolog.Ctx(ctx).Error().
+		Str("contextId", support.ContextId(ctx)).
+		Str("method", method).
+		Any("recovered", r).
+		Bytes("stack", debug.Stack()).
+		Msg("gRPC handler panicked")
+
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(fmt.Errorf("panic: %v", r))
+	span.SetStatus(otelcodes.Error, "handler panicked")
+
+	panicsMetric.Add(ctx, 1, metric.WithAttributes(attribute.String("method", method)))
+	return status.Error(codes.Internal, "internal error")
+}
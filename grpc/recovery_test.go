@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"context"
+	"github.com/iyarkov/kit/telemetry"
+	"go.opentelemetry.io/otel/metric/noop"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestRecovery(t *testing.T) {
+	telemetry.Meter = noop.NewMeterProvider().Meter("test")
+	ctx := context.Background()
+
+	unary, err := NewServerRecoveryInterceptor(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	resp, err := unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/kit.Test/Unary"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("secret details")
+	})
+	if resp != nil {
+		t.Errorf("Unexpected response %v", resp)
+	}
+	grpcStatus := status.Convert(err)
+	if grpcStatus.Code() != codes.Internal || grpcStatus.Message() != "internal error" {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	stream, err := NewStreamServerRecoveryInterceptor(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	err = stream(nil, &testServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/kit.Test/Stream"}, func(srv interface{}, stream grpc.ServerStream) error {
+		var values []int
+		return status.Error(codes.OK, string(rune(values[1])))
+	})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	err = stream(nil, &testServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/kit.Test/Stream"}, func(srv interface{}, stream grpc.ServerStream) error {
+		return status.Error(codes.NotFound, "not found")
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Handler errors must pass through, got %v", err)
+	}
+}
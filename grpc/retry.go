@@ -0,0 +1,270 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"github.com/iyarkov/kit/telemetry"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	defaultInitialBackoff    = 100 * time.Millisecond
+	defaultMaxBackoff        = 5 * time.Second
+	defaultBackoffMultiplier = 2.0
+	defaultBudgetTokens      = 10.0
+	defaultBudgetRatio       = 0.1
+)
+
+// RetryPolicy client call policy of a gRPC method. Timeout is applied when the caller context has no deadline.
+// Failed attempts with RetryableCodes (UNAVAILABLE by default, names or numbers in the configuration) are retried up
+// to MaxAttempts with exponential backoff and full jitter. Idempotent methods with HedgingDelay send the next attempt
+// every HedgingDelay without waiting for the previous one, the first successful response wins
+type RetryPolicy struct {
+	Timeout           time.Duration
+	MaxAttempts       int
+	InitialBackoff    time.Duration
+	MaxBackoff        time.Duration
+	BackoffMultiplier float64
+	RetryableCodes    []codes.Code
+	Idempotent        bool
+	HedgingDelay      time.Duration
+}
+
+// RetryConfiguration maps full method names to policies, the lookup is the same as in PolicyConfiguration. Retries
+// and hedged attempts share a budget: each failure takes a token, each success returns BudgetRatio of a token,
+// retries stop while less than half of BudgetTokens are left
+type RetryConfiguration struct {
+	Methods      map[string]RetryPolicy
+	BudgetTokens float64
+	BudgetRatio  float64
+}
+
+type retryBudget struct {
+	mu     sync.Mutex
+	tokens float64
+	max    float64
+	ratio  float64
+}
+
+func newRetryBudget(conf *RetryConfiguration) *retryBudget {
+	budget := retryBudget{
+		max:   conf.BudgetTokens,
+		ratio: conf.BudgetRatio,
+	}
+	if budget.max <= 0 {
+		budget.max = defaultBudgetTokens
+	}
+	if budget.ratio <= 0 {
+		budget.ratio = defaultBudgetRatio
+	}
+	budget.tokens = budget.max
+	return &budget
+}
+
+func (b *retryBudget) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.max, b.tokens+b.ratio)
+}
+
+func (b *retryBudget) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Max(0, b.tokens-1)
+}
+
+func (b *retryBudget) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens > b.max/2
+}
+
+func (p *RetryPolicy) isRetryable(code codes.Code) bool {
+	if len(p.RetryableCodes) == 0 {
+		return code == codes.Unavailable
+	}
+	for _, retryable := range p.RetryableCodes {
+		if retryable == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff delay before the retry, attempt starts with 1
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	initial := p.InitialBackoff
+	if initial <= 0 {
+		initial = defaultInitialBackoff
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+	multiplier := p.BackoffMultiplier
+	if multiplier < 1 {
+		multiplier = defaultBackoffMultiplier
+	}
+	limit := math.Min(float64(initial)*math.Pow(multiplier, float64(attempt-1)), float64(maxBackoff))
+	return time.Duration(rand.Int63n(int64(limit) + 1))
+}
+
+type retryInterceptor struct {
+	policies      map[string]RetryPolicy
+	budget        *retryBudget
+	attemptMetric metric.Int64Histogram
+}
+
+// NewClientRetryInterceptor Client Side Interceptor, applies the method RetryPolicy. Each attempt is a child span
+// and is recorded in the "grpc.client.attempt" histogram. Methods without a policy are called as is
+func NewClientRetryInterceptor(initCtx context.Context, conf *RetryConfiguration) (grpc.UnaryClientInterceptor, error) {
+	attemptMetric, err := telemetry.Meter.Int64Histogram("grpc.client.attempt", metric.WithUnit("ms"))
+	if err != nil {
+		return nil, err
+	}
+	for method, policy := range conf.Methods {
+		if policy.HedgingDelay > 0 && !policy.Idempotent {
+			return nil, fmt.Errorf("retry policy %s: hedging requires an idempotent method", method)
+		}
+	}
+	interceptor := retryInterceptor{
+		policies:      conf.Methods,
+		budget:        newRetryBudget(conf),
+		attemptMetric: attemptMetric,
+	}
+	zerolog.Ctx(initCtx).Debug().Msgf("retry interceptor initialized with %d policies", len(conf.Methods))
+	return interceptor.intercept, nil
+}
+
+func (r *retryInterceptor) intercept(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	policy, ok := findMethod(r.policies, method)
+	if !ok {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline && policy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.Timeout)
+		defer cancel()
+	}
+	if policy.HedgingDelay > 0 && policy.MaxAttempts > 1 {
+		if message, ok := reply.(proto.Message); ok {
+			return r.hedge(ctx, &policy, method, req, message, cc, invoker, opts...)
+		}
+	}
+	return r.retry(ctx, &policy, method, req, reply, cc, invoker, opts...)
+}
+
+func (r *retryInterceptor) retry(ctx context.Context, policy *RetryPolicy, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	for attempt := 1; ; attempt++ {
+		err := r.attempt(ctx, attempt, false, method, req, reply, cc, invoker, opts...)
+		if err == nil {
+			r.budget.success()
+			return nil
+		}
+		code := errorCode(err)
+		if !policy.isRetryable(code) {
+			return err
+		}
+		r.budget.failure()
+		if attempt >= policy.MaxAttempts || !r.budget.allow() {
+			return err
+		}
+		delay := policy.backoff(attempt)
+		zerolog.Ctx(ctx).Debug().Msgf("retry: %s failed with %s, attempt %d in %s", method, code, attempt+1, delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+type hedgeResult struct {
+	reply proto.Message
+	err   error
+}
+
+func (r *retryInterceptor) hedge(ctx context.Context, policy *RetryPolicy, method string, req interface{}, reply proto.Message, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	// pending attempts are cancelled once the winner is known
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgeResult, policy.MaxAttempts)
+	send := func(attempt int) {
+		attemptReply := proto.Clone(reply)
+		go func() {
+			err := r.attempt(ctx, attempt, attempt > 1, method, req, attemptReply, cc, invoker, opts...)
+			results <- hedgeResult{reply: attemptReply, err: err}
+		}()
+	}
+
+	sent, pending := 1, 1
+	send(sent)
+	timer := time.NewTimer(policy.HedgingDelay)
+	defer timer.Stop()
+	var lastErr error
+	for pending > 0 {
+		select {
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				r.budget.success()
+				proto.Reset(reply)
+				proto.Merge(reply, result.reply)
+				return nil
+			}
+			lastErr = result.err
+			if !policy.isRetryable(errorCode(result.err)) {
+				return result.err
+			}
+			r.budget.failure()
+			// no reason to wait for the hedging delay after a failure
+			if sent < policy.MaxAttempts && r.budget.allow() {
+				sent++
+				pending++
+				send(sent)
+			}
+		case <-timer.C:
+			if sent < policy.MaxAttempts && r.budget.allow() {
+				sent++
+				pending++
+				send(sent)
+				timer.Reset(policy.HedgingDelay)
+			}
+		}
+	}
+	return lastErr
+}
+
+func (r *retryInterceptor) attempt(ctx context.Context, attempt int, hedged bool, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx, span := telemetry.StartSpan(ctx, fmt.Sprintf("grpc%s/attempt", method))
+	defer span.End()
+	span.SetAttributes(attribute.Int("attempt", attempt), attribute.Bool("hedged", hedged))
+
+	startTime := time.Now()
+	// the server span is a child of the attempt span
+	err := invoker(withOutgoingTrace(ctx), method, req, reply, cc, opts...)
+	code := errorCode(err)
+	if err != nil {
+		span.SetStatus(otelcodes.Error, code.String())
+	}
+	r.attemptMetric.Record(ctx, time.Since(startTime).Milliseconds(), metric.WithAttributes(
+		attribute.String("method", method),
+		attribute.String("code", code.String()),
+		attribute.Bool("retry", attempt > 1),
+		attribute.Bool("hedged", hedged),
+	))
+	return err
+}
@@ -0,0 +1,190 @@
+package grpc
+
+import (
+	"context"
+	"github.com/iyarkov/kit/config"
+	"github.com/iyarkov/kit/telemetry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestRetry(t *testing.T, conf *RetryConfiguration) grpc.UnaryClientInterceptor {
+	telemetry.InitTelemetry(context.Background(), &telemetry.Configuration{})
+	interceptor, err := NewClientRetryInterceptor(context.Background(), conf)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	return interceptor
+}
+
+func TestClientRetry(t *testing.T) {
+	interceptor := newTestRetry(t, &RetryConfiguration{
+		Methods: map[string]RetryPolicy{
+			"/kit.Test/*": {MaxAttempts: 3, InitialBackoff: time.Millisecond},
+		},
+	})
+	type spec struct {
+		name     string
+		method   string
+		failures []codes.Code
+		code     codes.Code
+		attempts int32
+	}
+	suite := []spec{
+		{"success", "/kit.Test/Get", nil, codes.OK, 1},
+		{"retried", "/kit.Test/Get", []codes.Code{codes.Unavailable, codes.Unavailable}, codes.OK, 3},
+		{"attempts exhausted", "/kit.Test/Get", []codes.Code{codes.Unavailable, codes.Unavailable, codes.Unavailable}, codes.Unavailable, 3},
+		{"not retryable", "/kit.Test/Get", []codes.Code{codes.NotFound}, codes.NotFound, 1},
+		{"no policy", "/kit.Other/Get", []codes.Code{codes.Unavailable}, codes.Unavailable, 1},
+	}
+	for _, test := range suite {
+		t.Run(test.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				attempt := attempts.Add(1)
+				if int(attempt) <= len(test.failures) {
+					return status.Error(test.failures[attempt-1], "failed")
+				}
+				return nil
+			}
+			err := interceptor(context.Background(), test.method, nil, nil, nil, invoker)
+			if status.Code(err) != test.code {
+				t.Errorf("Expecting code %s, got %v", test.code, err)
+			}
+			if attempts.Load() != test.attempts {
+				t.Errorf("Expecting %d attempts, got %d", test.attempts, attempts.Load())
+			}
+		})
+	}
+}
+
+func TestClientRetryBudget(t *testing.T) {
+	interceptor := newTestRetry(t, &RetryConfiguration{
+		Methods:      map[string]RetryPolicy{"*": {MaxAttempts: 5, InitialBackoff: time.Millisecond}},
+		BudgetTokens: 4,
+	})
+	var attempts atomic.Int32
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		attempts.Add(1)
+		return status.Error(codes.Unavailable, "down")
+	}
+	_ = interceptor(context.Background(), "/kit.Test/Get", nil, nil, nil, invoker)
+	// 4 tokens, retries stop when 2 tokens are left
+	if attempts.Load() != 2 {
+		t.Errorf("Expecting 2 attempts, got %d", attempts.Load())
+	}
+	_ = interceptor(context.Background(), "/kit.Test/Get", nil, nil, nil, invoker)
+	if attempts.Load() != 3 {
+		t.Errorf("Expecting no retries with exhausted budget, got %d attempts", attempts.Load())
+	}
+}
+
+func TestClientRetryTimeout(t *testing.T) {
+	interceptor := newTestRetry(t, &RetryConfiguration{
+		Methods: map[string]RetryPolicy{"*": {Timeout: time.Minute}},
+	})
+	var deadline time.Time
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		deadline, _ = ctx.Deadline()
+		return nil
+	}
+	if err := interceptor(context.Background(), "/kit.Test/Get", nil, nil, nil, invoker); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if time.Until(deadline) < 50*time.Second {
+		t.Errorf("Expecting the default deadline, got %v", deadline)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	expected, _ := ctx.Deadline()
+	if err := interceptor(ctx, "/kit.Test/Get", nil, nil, nil, invoker); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if !deadline.Equal(expected) {
+		t.Errorf("Caller deadline must be kept, got %v", deadline)
+	}
+}
+
+func TestClientHedging(t *testing.T) {
+	interceptor := newTestRetry(t, &RetryConfiguration{
+		Methods: map[string]RetryPolicy{"*": {MaxAttempts: 3, Idempotent: true, HedgingDelay: 10 * time.Millisecond}},
+	})
+	var attempts atomic.Int32
+	var cancelled atomic.Bool
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		if attempts.Add(1) == 1 {
+			<-ctx.Done()
+			cancelled.Store(true)
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		reply.(*wrapperspb.StringValue).Value = "hedged"
+		return nil
+	}
+	reply := wrapperspb.String("")
+	if err := interceptor(context.Background(), "/kit.Test/Get", nil, reply, nil, invoker); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if reply.Value != "hedged" {
+		t.Errorf("Expecting the hedged reply, got [%s]", reply.Value)
+	}
+	if attempts.Load() != 2 {
+		t.Errorf("Expecting 2 attempts, got %d", attempts.Load())
+	}
+	time.Sleep(10 * time.Millisecond)
+	if !cancelled.Load() {
+		t.Errorf("Slow attempt must be cancelled")
+	}
+}
+
+func TestClientHedgingRequiresIdempotent(t *testing.T) {
+	_, err := NewClientRetryInterceptor(context.Background(), &RetryConfiguration{
+		Methods: map[string]RetryPolicy{"*": {MaxAttempts: 3, HedgingDelay: time.Millisecond}},
+	})
+	if err == nil {
+		t.Errorf("Expecting error")
+	}
+}
+
+func TestRetryConfigurationRead(t *testing.T) {
+	type spec struct {
+		name    string
+		file    string
+		content string
+		args    []string
+	}
+	suite := []spec{
+		{name: "yaml", file: "app.yaml", content: "methods:\n  \"*\":\n    retryable_codes: [UNAVAILABLE, 10]\n"},
+		{name: "json", file: "app.json", content: `{"Methods": {"*": {"RetryableCodes": ["UNAVAILABLE", 10]}}}`},
+		{name: "arguments", file: "app.json", content: `{"Methods": {"*": {}}}`,
+			args: []string{"Methods.*.RetryableCodes=UNAVAILABLE,ABORTED"}},
+	}
+	for _, test := range suite {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), test.file)
+			if err := os.WriteFile(path, []byte(test.content), 0600); err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			previous := os.Args
+			os.Args = append([]string{"app", "-f" + path}, test.args...)
+			defer func() { os.Args = previous }()
+
+			var conf RetryConfiguration
+			if err := config.Read(&conf); err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			expected := []codes.Code{codes.Unavailable, codes.Aborted}
+			if actual := conf.Methods["*"].RetryableCodes; !reflect.DeepEqual(expected, actual) {
+				t.Errorf("Expecting %v, got %v", expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/iyarkov/kit/auth"
+	"github.com/iyarkov/kit/health"
+	"github.com/iyarkov/kit/logger"
+	"github.com/iyarkov/kit/support"
+	"github.com/iyarkov/kit/tls"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"net"
+	"os"
+	"time"
+)
+
+const defaultShutdownTimeout = time.Second * 30
+
+var healthPolicy = "/grpc.health.v1.Health/*"
+
+var ErrorAdminRequiresAuth = errors.New("admin service requires auth and policy")
+
+// Configuration of the gRPC server and clients. Context id and connection info interceptors are always installed,
+// the other pieces can be disabled. ShutdownDelay is the time between the readiness flip and the graceful stop, it
+// gives the load balancers time to notice. EnableAdmin allows RegisterAdmin, it requires auth and policy. The server
+// verifies the tokens with Verifier and the clients sign them with Signer, the auth package ones set by auth.InitAuth
+// when nil
+type Configuration struct {
+	Address         string
+	ShutdownTimeout time.Duration
+	ShutdownDelay   time.Duration
+	Tls             tls.Configuration
+	Auth            auth.Configuration
+	Policy          PolicyConfiguration
+	Retry           RetryConfiguration
+	Limit           LimitConfiguration
+	Metric          MetricConfiguration
+	Health          health.Configuration
+	Idempotency     IdempotencyConfiguration
+	Signer          auth.Signer   `config:"-"`
+	Verifier        auth.Verifier `config:"-"`
+
+	DisableAuth       bool
+	DisablePolicy     bool
+	DisableTrace      bool
+	DisableMetric     bool
+	DisableHealth     bool
+	DisableErrors     bool
+	DisableRecovery   bool
+	DisableValidation bool
+	EnableAdmin       bool
+}
+
+// Server gRPC server with the kit interceptor chain, health service and graceful shutdown on SIGTERM. Register the
+// component checkers in Health
+type Server struct {
+	*grpc.Server
+	Health *health.Registry
+
+	cfg *Configuration
+}
+
+// NewServer builds the server, the interceptors run in order: context id, connection info, trace, recovery, metric,
+// auth, limit, policy, validation, idempotency, errors, then the interceptors from opts. The limit interceptors are
+// installed when Limit has method limits, the idempotency interceptor when Idempotency has a Store
+func NewServer(ctx context.Context, cfg *Configuration, opts ...grpc.ServerOption) (*Server, error) {
+	if cfg.EnableAdmin && (cfg.DisableAuth || cfg.DisablePolicy) {
+		return nil, ErrorAdminRequiresAuth
+	}
+	tlsConfig, err := cfg.Tls.NewCryptoTlsConfig()
+	if err != nil {
+		return nil, fmt.Errorf("tls config failed: %w", err)
+	}
+
+	unary, stream, err := serverInterceptors(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	serverOpts := []grpc.ServerOption{
+		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+	server := Server{
+		Server: grpc.NewServer(append(serverOpts, opts...)...),
+		cfg:    cfg,
+	}
+	if !cfg.DisableHealth {
+		server.Health = health.NewRegistry(&cfg.Health)
+		healthpb.RegisterHealthServer(server.Server, server.Health.GrpcServer())
+	}
+	return &server, nil
+}
+
+// serverInterceptors the NewServer interceptor chain
+func serverInterceptors(ctx context.Context, cfg *Configuration) ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor, error) {
+	unary := []grpc.UnaryServerInterceptor{ServerContextId, ServerConnectionInfo}
+	stream := []grpc.StreamServerInterceptor{StreamServerContextId, StreamServerConnectionInfo}
+	if !cfg.DisableTrace {
+		unary = append(unary, ServerTrace)
+		stream = append(stream, StreamServerTrace)
+	}
+	if !cfg.DisableRecovery {
+		unaryRecovery, err := NewServerRecoveryInterceptor(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("recovery interceptor failed: %w", err)
+		}
+		streamRecovery, err := NewStreamServerRecoveryInterceptor(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("stream recovery interceptor failed: %w", err)
+		}
+		unary = append(unary, unaryRecovery)
+		stream = append(stream, streamRecovery)
+	}
+	if !cfg.DisableMetric {
+		unaryMetric, streamMetric, err := NewServerMetricInterceptors(ctx, &cfg.Metric)
+		if err != nil {
+			return nil, nil, fmt.Errorf("metric interceptors failed: %w", err)
+		}
+		unary = append(unary, unaryMetric)
+		stream = append(stream, streamMetric)
+	}
+	if !cfg.DisableAuth {
+		unaryAuth, streamAuth, err := NewServerAuthInterceptors(ctx, &cfg.Auth, cfg.Verifier)
+		if err != nil {
+			return nil, nil, fmt.Errorf("auth interceptors failed: %w", err)
+		}
+		unary = append(unary, unaryAuth)
+		stream = append(stream, streamAuth)
+	}
+	if len(cfg.Limit.Methods) > 0 {
+		unaryLimit, streamLimit, err := NewServerLimitInterceptors(ctx, &cfg.Limit)
+		if err != nil {
+			return nil, nil, fmt.Errorf("limit interceptors failed: %w", err)
+		}
+		unary = append(unary, unaryLimit)
+		stream = append(stream, streamLimit)
+	}
+	if !cfg.DisablePolicy {
+		policy := serverPolicy(cfg)
+		unaryPolicy, err := NewServerPolicyInterceptor(ctx, &policy)
+		if err != nil {
+			return nil, nil, fmt.Errorf("policy interceptor failed: %w", err)
+		}
+		streamPolicy, err := NewStreamServerPolicyInterceptor(ctx, &policy)
+		if err != nil {
+			return nil, nil, fmt.Errorf("stream policy interceptor failed: %w", err)
+		}
+		unary = append(unary, unaryPolicy)
+		stream = append(stream, streamPolicy)
+	}
+	if !cfg.DisableValidation {
+		unary = append(unary, ServerValidation)
+		stream = append(stream, StreamServerValidation)
+	}
+	if cfg.Idempotency.Store != nil {
+		idempotencyInterceptor, err := NewServerIdempotencyInterceptor(ctx, &cfg.Idempotency)
+		if err != nil {
+			return nil, nil, fmt.Errorf("idempotency interceptor failed: %w", err)
+		}
+		unary = append(unary, idempotencyInterceptor)
+	}
+	if !cfg.DisableErrors {
+		unary = append(unary, ServerErrors)
+		stream = append(stream, StreamServerErrors)
+	}
+	return unary, stream, nil
+}
+
+// serverPolicy the configured policies over the built-in services defaults
+func serverPolicy(cfg *Configuration) PolicyConfiguration {
+	defaults := make(map[string]Policy)
+	if !cfg.DisableHealth {
+		defaults[healthPolicy] = Policy{Anonymous: true}
+	}
+	if cfg.EnableAdmin {
+		for _, method := range adminPolicies {
+			defaults[method] = adminPolicy
+		}
+	}
+	policy := PolicyConfiguration{
+		Methods: make(map[string]Policy, len(cfg.Policy.Methods)+len(defaults)),
+	}
+	for method, methodPolicy := range defaults {
+		policy.Methods[method] = methodPolicy
+	}
+	for method, methodPolicy := range cfg.Policy.Methods {
+		policy.Methods[method] = methodPolicy
+	}
+	return policy
+}
+
+// ListenAndServe serves until the server is stopped, the health HTTP endpoints are served when Health has an Address.
+// On SIGTERM the health service reports NOT_SERVING and the server stops gracefully, pending calls are cancelled after
+// ShutdownTimeout
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.cfg.Address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.cfg.Address, err)
+	}
+	support.OnSigTerm(func(shutdownCtx context.Context, signal os.Signal) {
+		shutdownCtx = logger.WithLogger(shutdownCtx)
+		s.Shutdown(shutdownCtx)
+	})
+	if s.Health != nil {
+		go func() {
+			if err := s.Health.ListenAndServe(ctx); err != nil {
+				zerolog.Ctx(ctx).Error().Err(err).Msg("health HTTP server failed")
+			}
+		}()
+	}
+	zerolog.Ctx(ctx).Info().Msgf("gRPC server listening on %s", listener.Addr())
+	err = s.Serve(listener)
+	if err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops the server gracefully
+func (s *Server) Shutdown(ctx context.Context) {
+	log := zerolog.Ctx(ctx)
+	log.Info().Msg("Shutting down gRPC server")
+	if s.Health != nil {
+		s.Health.Shutdown()
+		defer s.Health.Close(ctx)
+	}
+	if s.cfg.ShutdownDelay > 0 {
+		time.Sleep(s.cfg.ShutdownDelay)
+	}
+	timeout := s.cfg.ShutdownTimeout
+	if timeout == 0 {
+		timeout = defaultShutdownTimeout
+	}
+	stopped := make(chan bool)
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		log.Info().Msg("gRPC server stopped")
+	case <-time.After(timeout):
+		log.Warn().Msg("gRPC server graceful stop timeout")
+		s.Stop()
+	}
+}
+
+// Dial connects to the target with the client interceptor chain: errors, context id, trace, metric, retry, auth,
+// then the interceptors from opts. The retry interceptor is installed when Retry has method policies
+func Dial(ctx context.Context, cfg *Configuration, target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	tlsConfig, err := cfg.Tls.NewCryptoTlsConfig()
+	if err != nil {
+		return nil, fmt.Errorf("tls config failed: %w", err)
+	}
+	unary, stream, err := clientInterceptors(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		grpc.WithChainUnaryInterceptor(unary...),
+		grpc.WithChainStreamInterceptor(stream...),
+	}
+	conn, err := grpc.DialContext(ctx, target, append(dialOpts, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", target, err)
+	}
+	return conn, nil
+}
+
+// clientInterceptors the Dial interceptor chain
+func clientInterceptors(ctx context.Context, cfg *Configuration) ([]grpc.UnaryClientInterceptor, []grpc.StreamClientInterceptor, error) {
+	var unary []grpc.UnaryClientInterceptor
+	var stream []grpc.StreamClientInterceptor
+	if !cfg.DisableErrors {
+		unary = append(unary, ClientErrors)
+		stream = append(stream, StreamClientErrors)
+	}
+	unary = append(unary, ClientContextId)
+	stream = append(stream, StreamClientContextId)
+	if !cfg.DisableTrace {
+		unary = append(unary, ClientTrace)
+		stream = append(stream, StreamClientTrace)
+	}
+	if !cfg.DisableMetric {
+		unaryMetric, err := NewClientMetricInterceptor(ctx, &cfg.Metric)
+		if err != nil {
+			return nil, nil, fmt.Errorf("client metric interceptor failed: %w", err)
+		}
+		streamMetric, err := NewStreamClientMetricInterceptor(ctx, &cfg.Metric)
+		if err != nil {
+			return nil, nil, fmt.Errorf("stream client metric interceptor failed: %w", err)
+		}
+		unary = append(unary, unaryMetric)
+		stream = append(stream, streamMetric)
+	}
+	if len(cfg.Retry.Methods) > 0 {
+		retry, err := NewClientRetryInterceptor(ctx, &cfg.Retry)
+		if err != nil {
+			return nil, nil, fmt.Errorf("retry interceptor failed: %w", err)
+		}
+		unary = append(unary, retry)
+	}
+	if !cfg.DisableAuth {
+		unary = append(unary, NewClientAuth(cfg.Signer))
+		stream = append(stream, NewStreamClientAuth(cfg.Signer))
+	}
+	return unary, stream, nil
+}
@@ -0,0 +1,760 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	cryptotls "crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"github.com/iyarkov/kit/auth"
+	"github.com/iyarkov/kit/config"
+	kiterrors "github.com/iyarkov/kit/errors"
+	"github.com/iyarkov/kit/idempotency"
+	"github.com/iyarkov/kit/logger"
+	"github.com/iyarkov/kit/support"
+	"github.com/iyarkov/kit/support/protobuf"
+	"github.com/iyarkov/kit/telemetry"
+	"github.com/iyarkov/kit/tls"
+	sdk "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	semconv "go.opentelemetry.io/otel/semconv/v1.18.0"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testServerConfiguration the gateway peer is trusted, the kit.Test methods require the manager role. The auth
+// package signer and verifier are not set
+func testServerConfiguration(t *testing.T) *Configuration {
+	telemetry.InitTelemetry(context.Background(), &telemetry.Configuration{})
+	authConfig := auth.Configuration{
+		TrustedPeers:   []string{"gateway"},
+		UntrustedRoles: []string{"user"},
+		SigningKey:     1,
+		Keys:           []auth.Key{{Id: 1, Secret: config.NewPassword(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)))}},
+	}
+	signer, verifier, err := auth.NewSignerAndVerifier(&authConfig)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	return &Configuration{
+		Auth: authConfig,
+		Policy: PolicyConfiguration{
+			Methods: map[string]Policy{"/kit.Test/*": {Roles: []string{"manager"}}},
+		},
+		Signer:   signer,
+		Verifier: verifier,
+	}
+}
+
+func testServerToken(t *testing.T, cfg *Configuration, accountId uint64, role auth.Role) string {
+	token := auth.Token{AccountId: accountId, Role: role, ExpiresAt: time.Now().Add(time.Hour)}
+	encoded, err := token.SignToString(cfg.Signer)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	return encoded
+}
+
+// incomingContext the context of a call from the peer with the certificate common name, as the server transport
+// creates it
+func incomingContext(peerName string, pairs ...string) context.Context {
+	certificate := &x509.Certificate{Subject: pkix.Name{CommonName: peerName}, SerialNumber: big.NewInt(1)}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: cryptotls.ConnectionState{PeerCertificates: []*x509.Certificate{certificate}}},
+	})
+	return metadata.NewIncomingContext(ctx, metadata.Pairs(append([]string{contextIdMeta, "ctx-1"}, pairs...)...))
+}
+
+// callUnary runs the handler through the interceptors the way grpc.ChainUnaryInterceptor does
+func callUnary(ctx context.Context, interceptors []grpc.UnaryServerInterceptor, method string, req interface{}, handler grpc.UnaryHandler) (interface{}, error) {
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+			return interceptor(ctx, req, info, next)
+		}
+	}
+	return handler(ctx, req)
+}
+
+func callStream(ctx context.Context, interceptors []grpc.StreamServerInterceptor, method string, handler grpc.StreamHandler) error {
+	info := &grpc.StreamServerInfo{FullMethod: method}
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		handler = func(srv interface{}, stream grpc.ServerStream) error {
+			return interceptor(srv, stream, info, next)
+		}
+	}
+	return handler(nil, &testServerStream{ctx: ctx})
+}
+
+// callerInfo what the handler sees at the end of the chain
+type callerInfo struct {
+	contextId string
+	peer      string
+	accountId uint64
+}
+
+func newCallerInfo(ctx context.Context) callerInfo {
+	return callerInfo{
+		contextId: support.ContextId(ctx),
+		peer:      ConnectionInfo(ctx).Peer,
+		accountId: auth.AuthToken(ctx).AccountId,
+	}
+}
+
+// recordedCodes the status codes of the recorded durations
+func recordedCodes(t *testing.T, reader sdk.Reader) map[string]bool {
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &data); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	result := make(map[string]bool)
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			histogram, ok := m.Data.(metricdata.Histogram[float64])
+			if !ok {
+				continue
+			}
+			for _, point := range histogram.DataPoints {
+				if code, ok := point.Attributes.Value(semconv.RPCGRPCStatusCodeKey); ok {
+					result[codes.Code(code.AsInt64()).String()] = true
+				}
+			}
+		}
+	}
+	return result
+}
+
+func TestServerInterceptorChain(t *testing.T) {
+	cfg := testServerConfiguration(t)
+	reader := sdk.NewManualReader()
+	telemetry.Meter = sdk.NewMeterProvider(sdk.WithReader(reader)).Meter("test")
+	unary, stream, err := serverInterceptors(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	admin := testServerToken(t, cfg, 4, auth.Admin)
+
+	type spec struct {
+		name     string
+		ctx      context.Context
+		expected codes.Code
+	}
+	// the trusted admin passes only if the connection info runs before auth and auth before policy
+	suite := []spec{
+		{"trusted admin", incomingContext("gateway", authTokenMeta, admin), codes.OK},
+		{"untrusted admin", incomingContext("partner", authTokenMeta, admin), codes.PermissionDenied},
+		{"anonymous", incomingContext("partner"), codes.Unauthenticated},
+	}
+	for _, test := range suite {
+		t.Run(test.name, func(t *testing.T) {
+			var unaryCaller, streamCaller callerInfo
+			_, err := callUnary(test.ctx, unary, "/kit.Test/Get", nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+				unaryCaller = newCallerInfo(ctx)
+				return "ok", nil
+			})
+			if status.Code(err) != test.expected {
+				t.Fatalf("Expecting %s, got %v", test.expected, err)
+			}
+			err = callStream(test.ctx, stream, "/kit.Test/Watch", func(srv interface{}, stream grpc.ServerStream) error {
+				streamCaller = newCallerInfo(stream.Context())
+				return nil
+			})
+			if status.Code(err) != test.expected {
+				t.Fatalf("Expecting stream %s, got %v", test.expected, err)
+			}
+			if test.expected != codes.OK {
+				return
+			}
+			expected := callerInfo{contextId: "ctx-1", peer: "gateway", accountId: 4}
+			if unaryCaller != expected || streamCaller != expected {
+				t.Errorf("Expecting the handlers to see %+v, got %+v and %+v", expected, unaryCaller, streamCaller)
+			}
+		})
+	}
+
+	// the metric interceptor runs before auth and policy, the rejected calls are recorded
+	codesRecorded := recordedCodes(t, reader)
+	for _, code := range []codes.Code{codes.OK, codes.PermissionDenied, codes.Unauthenticated} {
+		if !codesRecorded[code.String()] {
+			t.Errorf("Expecting %s to be recorded, got %v", code, codesRecorded)
+		}
+	}
+}
+
+func TestServerInterceptorChainDisabled(t *testing.T) {
+	type spec struct {
+		name     string
+		update   func(cfg *Configuration)
+		ctx      context.Context
+		expected codes.Code
+		caller   callerInfo
+	}
+	suite := []spec{
+		{"policy", func(cfg *Configuration) { cfg.DisablePolicy = true }, incomingContext("partner"), codes.OK,
+			callerInfo{contextId: "ctx-1", peer: "partner"}},
+		{"auth", func(cfg *Configuration) { cfg.DisableAuth, cfg.DisablePolicy = true, true },
+			incomingContext("gateway", authTokenMeta, "not a token"), codes.OK, callerInfo{contextId: "ctx-1", peer: "gateway"}},
+		{"trace", func(cfg *Configuration) { cfg.DisableTrace = true }, incomingContext("partner"), codes.Unauthenticated,
+			callerInfo{}},
+	}
+	for _, test := range suite {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := testServerConfiguration(t)
+			test.update(cfg)
+			unary, _, err := serverInterceptors(context.Background(), cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			var caller callerInfo
+			_, err = callUnary(test.ctx, unary, "/kit.Test/Get", nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+				caller = newCallerInfo(ctx)
+				return "ok", nil
+			})
+			if status.Code(err) != test.expected {
+				t.Fatalf("Expecting %s, got %v", test.expected, err)
+			}
+			if caller != test.caller {
+				t.Errorf("Expecting the handler to see %+v, got %+v", test.caller, caller)
+			}
+		})
+	}
+
+	cfg := testServerConfiguration(t)
+	cfg.DisableMetric = true
+	reader := sdk.NewManualReader()
+	telemetry.Meter = sdk.NewMeterProvider(sdk.WithReader(reader)).Meter("test")
+	unary, _, err := serverInterceptors(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	_, _ = callUnary(incomingContext("partner"), unary, "/kit.Test/Get", nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	if recorded := recordedCodes(t, reader); len(recorded) != 0 {
+		t.Errorf("Expecting no metrics, got %v", recorded)
+	}
+}
+
+func TestServerHealthPolicy(t *testing.T) {
+	type spec struct {
+		name     string
+		update   func(cfg *Configuration)
+		expected codes.Code
+	}
+	suite := []spec{
+		{"anonymous", func(cfg *Configuration) {}, codes.OK},
+		{"configured", func(cfg *Configuration) {
+			cfg.Policy.Methods[healthPolicy] = Policy{Roles: []string{"operator"}}
+		}, codes.Unauthenticated},
+		{"disabled", func(cfg *Configuration) { cfg.DisableHealth = true }, codes.PermissionDenied},
+	}
+	for _, test := range suite {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := testServerConfiguration(t)
+			test.update(cfg)
+			unary, _, err := serverInterceptors(context.Background(), cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			_, err = callUnary(incomingContext("partner"), unary, "/grpc.health.v1.Health/Check", nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return "ok", nil
+			})
+			if status.Code(err) != test.expected {
+				t.Errorf("Expecting %s, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestServerAdminPolicy(t *testing.T) {
+	type spec struct {
+		name     string
+		update   func(cfg *Configuration)
+		role     auth.Role
+		expected codes.Code
+	}
+	suite := []spec{
+		{"operator", func(cfg *Configuration) { cfg.EnableAdmin = true }, auth.Operator, codes.OK},
+		{"user", func(cfg *Configuration) { cfg.EnableAdmin = true }, auth.User, codes.PermissionDenied},
+		{"disabled", func(cfg *Configuration) {}, auth.Admin, codes.PermissionDenied},
+		{"configured", func(cfg *Configuration) {
+			cfg.EnableAdmin = true
+			cfg.Policy.Methods[adminPolicies[0]] = Policy{Roles: []string{"user"}}
+		}, auth.User, codes.OK},
+	}
+	for _, test := range suite {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := testServerConfiguration(t)
+			test.update(cfg)
+			unary, _, err := serverInterceptors(context.Background(), cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			ctx := incomingContext("gateway", authTokenMeta, testServerToken(t, cfg, 4, test.role))
+			_, err = callUnary(ctx, unary, "/iyarkov.kit.Admin/GetManifest", nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return "ok", nil
+			})
+			if status.Code(err) != test.expected {
+				t.Errorf("Expecting %s, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestServerAdminRequiresAuth(t *testing.T) {
+	for _, update := range []func(cfg *Configuration){
+		func(cfg *Configuration) { cfg.DisableAuth = true },
+		func(cfg *Configuration) { cfg.DisablePolicy = true },
+	} {
+		cfg := testServerConfiguration(t)
+		cfg.EnableAdmin = true
+		update(cfg)
+		if _, err := NewServer(context.Background(), cfg); !errors.Is(err, ErrorAdminRequiresAuth) {
+			t.Errorf("Expecting admin requires auth error, got %v", err)
+		}
+	}
+}
+
+func TestServerAuthVerifier(t *testing.T) {
+	cfg := testServerConfiguration(t)
+	cfg.Tls = testCertificates(t)
+	if _, err := NewServer(context.Background(), cfg); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	token := auth.Token{AccountId: 4, Role: auth.Admin, ExpiresAt: time.Now().Add(time.Hour)}
+	if _, err := token.WriteToString(); !errors.Is(err, auth.ErrorNoSigner) {
+		t.Errorf("Expecting the auth package signer to stay unset, got %v", err)
+	}
+
+	// without the Verifier the tokens are verified by the auth package verifier
+	cfg.Verifier = nil
+	unary, _, err := serverInterceptors(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	call := func() error {
+		ctx := incomingContext("gateway", authTokenMeta, testServerToken(t, cfg, 4, auth.Admin))
+		_, err := callUnary(ctx, unary, "/kit.Test/Get", nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return "ok", nil
+		})
+		return err
+	}
+	if err = call(); status.Code(err) != codes.Internal {
+		t.Errorf("Expecting %s without a verifier, got %v", codes.Internal, err)
+	}
+	withTestKeyring(t, 1)
+	if err = call(); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestServerErrorsChain(t *testing.T) {
+	for _, disabled := range []bool{false, true} {
+		cfg := testServerConfiguration(t)
+		cfg.DisableErrors = disabled
+		unary, stream, err := serverInterceptors(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		expected := codes.NotFound
+		if disabled {
+			expected = codes.Unknown
+		}
+		ctx := incomingContext("gateway", authTokenMeta, testServerToken(t, cfg, 4, auth.Admin))
+		_, err = callUnary(ctx, unary, "/kit.Test/Get", nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, kiterrors.NotFound("account", "4")
+		})
+		if status.Code(err) != expected {
+			t.Errorf("Errors disabled %t, expecting %s, got %v", disabled, expected, err)
+		}
+		err = callStream(ctx, stream, "/kit.Test/Watch", func(srv interface{}, stream grpc.ServerStream) error {
+			return kiterrors.NotFound("account", "4")
+		})
+		if status.Code(err) != expected {
+			t.Errorf("Errors disabled %t, expecting stream %s, got %v", disabled, expected, err)
+		}
+	}
+}
+
+func TestServerRecoveryChain(t *testing.T) {
+	for _, disabled := range []bool{false, true} {
+		cfg := testServerConfiguration(t)
+		cfg.DisableRecovery = disabled
+		unary, _, err := serverInterceptors(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		ctx := incomingContext("gateway", authTokenMeta, testServerToken(t, cfg, 4, auth.Admin))
+		var recovered interface{}
+		func() {
+			defer func() { recovered = recover() }()
+			_, err = callUnary(ctx, unary, "/kit.Test/Get", nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+				panic("test panic")
+			})
+		}()
+		if disabled && recovered == nil {
+			t.Errorf("Expecting the panic to propagate when recovery is disabled")
+		}
+		if !disabled && (recovered != nil || status.Code(err) != codes.Internal) {
+			t.Errorf("Expecting %s, got %v, recovered %v", codes.Internal, err, recovered)
+		}
+	}
+}
+
+func TestServerLimitChain(t *testing.T) {
+	cfg := testServerConfiguration(t)
+	cfg.Limit = LimitConfiguration{
+		Methods: map[string][]Limit{"*": {{Key: LimitByAccount, Rate: 0.01, Burst: 1}}},
+	}
+	unary, _, err := serverInterceptors(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	// the limit runs after auth, the calls are limited by the token account
+	type spec struct {
+		name      string
+		accountId uint64
+		expected  codes.Code
+	}
+	suite := []spec{
+		{"first", 4, codes.OK},
+		{"second", 4, codes.ResourceExhausted},
+		{"another account", 5, codes.OK},
+	}
+	for _, test := range suite {
+		ctx := incomingContext("gateway", authTokenMeta, testServerToken(t, cfg, test.accountId, auth.Admin))
+		_, err = callUnary(ctx, unary, "/kit.Test/Get", nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return "ok", nil
+		})
+		if status.Code(err) != test.expected {
+			t.Errorf("%s: expecting %s, got %v", test.name, test.expected, err)
+		}
+	}
+}
+
+// countingStore counts the reservations
+type countingStore struct {
+	idempotency.Store
+	reserved int
+}
+
+func (s *countingStore) Reserve(ctx context.Context, key idempotency.Key, requestHash []byte, lockTimeout time.Duration) (*idempotency.Record, error) {
+	s.reserved++
+	return s.Store.Reserve(ctx, key, requestHash, lockTimeout)
+}
+
+func TestServerIdempotencyChain(t *testing.T) {
+	cfg := testServerConfiguration(t)
+	store := &countingStore{Store: idempotency.NewMemoryStore()}
+	cfg.Idempotency.Store = store
+	unary, _, err := serverInterceptors(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	// the idempotency runs after auth, the responses are kept per account
+	type spec struct {
+		name      string
+		accountId uint64
+		handled   int
+	}
+	suite := []spec{
+		{"first", 4, 1},
+		{"duplicate", 4, 1},
+		{"another account", 5, 2},
+	}
+	handled := 0
+	for _, test := range suite {
+		token := testServerToken(t, cfg, test.accountId, auth.Admin)
+		ctx := incomingContext("gateway", authTokenMeta, token, idempotencyKeyMeta, "key-1")
+		_, err = callUnary(ctx, unary, "/kit.Test/Create", &protobuf.ObjectRequest{Id: "1"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			handled++
+			return &protobuf.ObjectRequest{Id: "1"}, nil
+		})
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", test.name, err)
+		}
+		if handled != test.handled {
+			t.Errorf("%s: expecting the handler to be called %d times, got %d", test.name, test.handled, handled)
+		}
+	}
+	if store.reserved != len(suite) {
+		t.Errorf("Expecting %d reservations, got %d", len(suite), store.reserved)
+	}
+}
+
+func TestServerValidationChain(t *testing.T) {
+	for _, disabled := range []bool{false, true} {
+		cfg := testServerConfiguration(t)
+		store := &countingStore{Store: idempotency.NewMemoryStore()}
+		cfg.Idempotency.Store = store
+		cfg.DisableValidation = disabled
+		unary, _, err := serverInterceptors(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		// the validation runs before the idempotency, the invalid requests do not reserve keys
+		token := testServerToken(t, cfg, 4, auth.Admin)
+		ctx := incomingContext("gateway", authTokenMeta, token, idempotencyKeyMeta, "key-1")
+		_, err = callUnary(ctx, unary, "/kit.Test/Create", &protobuf.ObjectRequest{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return &protobuf.ObjectRequest{Id: "1"}, nil
+		})
+		expected, reserved := codes.InvalidArgument, 0
+		if disabled {
+			expected, reserved = codes.OK, 1
+		}
+		if status.Code(err) != expected {
+			t.Errorf("Validation disabled %t, expecting %s, got %v", disabled, expected, err)
+		}
+		if store.reserved != reserved {
+			t.Errorf("Validation disabled %t, expecting %d reservations, got %d", disabled, reserved, store.reserved)
+		}
+	}
+}
+
+// sentMetadata what the client interceptors put into the outgoing metadata
+type sentMetadata struct {
+	contextId string
+	accountId uint64
+}
+
+func newSentMetadata(t *testing.T, cfg *Configuration, ctx context.Context) sentMetadata {
+	meta, _ := metadata.FromOutgoingContext(ctx)
+	var result sentMetadata
+	if values := meta.Get(contextIdMeta); len(values) > 0 {
+		result.contextId = values[0]
+	}
+	if values := meta.Get(authTokenMeta); len(values) > 0 {
+		token, err := auth.VerifyString(values[0], cfg.Verifier)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		result.accountId = token.AccountId
+	}
+	return result
+}
+
+// invokeUnary runs the invoker through the interceptors the way grpc.WithChainUnaryInterceptor does
+func invokeUnary(ctx context.Context, interceptors []grpc.UnaryClientInterceptor, method string, invoker grpc.UnaryInvoker) error {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoker
+		invoker = func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return interceptor(ctx, method, req, reply, cc, next, opts...)
+		}
+	}
+	return invoker(ctx, method, nil, nil, nil)
+}
+
+func openStream(ctx context.Context, interceptors []grpc.StreamClientInterceptor, method string, streamer grpc.Streamer) (grpc.ClientStream, error) {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], streamer
+		streamer = func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return interceptor(ctx, desc, cc, method, next, opts...)
+		}
+	}
+	return streamer(ctx, &grpc.StreamDesc{}, nil, method)
+}
+
+// testClientContext the context of a call made by the account 4, the client interceptors sign the token
+func testClientContext() context.Context {
+	ctx := logger.WithContextIdAndLogger(context.Background(), "ctx-1")
+	return auth.WithToken(ctx, &auth.Token{AccountId: 4, Role: auth.User, ExpiresAt: time.Now().Add(time.Hour)})
+}
+
+func TestClientInterceptorChain(t *testing.T) {
+	cfg := testServerConfiguration(t)
+	ctx := testClientContext()
+
+	type spec struct {
+		name     string
+		update   func(cfg *Configuration)
+		expected sentMetadata
+	}
+	suite := []spec{
+		{"all", func(cfg *Configuration) {}, sentMetadata{contextId: "ctx-1", accountId: 4}},
+		{"auth", func(cfg *Configuration) { cfg.DisableAuth = true }, sentMetadata{contextId: "ctx-1"}},
+	}
+	for _, test := range suite {
+		t.Run(test.name, func(t *testing.T) {
+			clientCfg := *cfg
+			test.update(&clientCfg)
+			unary, stream, err := clientInterceptors(ctx, &clientCfg)
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			var unarySent, streamSent sentMetadata
+			err = invokeUnary(ctx, unary, "/kit.Test/Get", func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				unarySent = newSentMetadata(t, cfg, ctx)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			_, err = openStream(ctx, stream, "/kit.Test/Watch", func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				streamSent = newSentMetadata(t, cfg, ctx)
+				return nil, nil
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if unarySent != test.expected || streamSent != test.expected {
+				t.Errorf("Expecting %+v to be sent, got %+v and %+v", test.expected, unarySent, streamSent)
+			}
+		})
+	}
+}
+
+func TestClientErrorsChain(t *testing.T) {
+	cfg := testServerConfiguration(t)
+	ctx := testClientContext()
+	for _, disabled := range []bool{false, true} {
+		clientCfg := *cfg
+		clientCfg.DisableErrors = disabled
+		unary, stream, err := clientInterceptors(ctx, &clientCfg)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		notFound := ToStatus(kiterrors.NotFound("account", "4")).Err()
+		unaryErr := invokeUnary(ctx, unary, "/kit.Test/Get", func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return notFound
+		})
+		_, streamErr := openStream(ctx, stream, "/kit.Test/Watch", func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return nil, notFound
+		})
+		for _, err := range []error{unaryErr, streamErr} {
+			var target *kiterrors.NotFoundError
+			if errors.As(err, &target) == disabled {
+				t.Errorf("Errors disabled %t, unexpected conversion of %v", disabled, err)
+			}
+		}
+	}
+}
+
+func TestClientRetryChain(t *testing.T) {
+	cfg := testServerConfiguration(t)
+	cfg.Retry = RetryConfiguration{
+		Methods: map[string]RetryPolicy{"*": {MaxAttempts: 3, InitialBackoff: time.Millisecond}},
+	}
+	ctx := testClientContext()
+	unary, _, err := clientInterceptors(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	// the retry runs before auth, each attempt is signed
+	var attempts []sentMetadata
+	err = invokeUnary(ctx, unary, "/kit.Test/Get", func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		attempts = append(attempts, newSentMetadata(t, cfg, ctx))
+		if len(attempts) < 3 {
+			return status.Error(codes.Unavailable, "unavailable")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(attempts) != 3 {
+		t.Fatalf("Expecting 3 attempts, got %d", len(attempts))
+	}
+	for _, attempt := range attempts {
+		if expected := (sentMetadata{contextId: "ctx-1", accountId: 4}); attempt != expected {
+			t.Errorf("Expecting %+v to be sent, got %+v", expected, attempt)
+		}
+	}
+}
+
+// testCertificates self-signed certificate used as the CA and the app certificate
+func testCertificates(t *testing.T) tls.Configuration {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "app"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	privateKey, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "app.crt"), filepath.Join(dir, "app.key")
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0600); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateKey}), 0600); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	return tls.Configuration{CACert: certFile, AppCert: certFile, AppKey: keyFile, KnownPeers: []string{"app"}}
+}
+
+func TestNewServerAndDial(t *testing.T) {
+	ctx := context.Background()
+	cfg := testServerConfiguration(t)
+	cfg.Tls = testCertificates(t)
+	cfg.EnableAdmin = true
+
+	server, err := NewServer(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if _, ok := server.GetServiceInfo()["grpc.health.v1.Health"]; !ok || server.Health == nil {
+		t.Errorf("Expecting the health service to be registered")
+	}
+	if err = server.RegisterAdmin(ctx, cfg, nil); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if _, ok := server.GetServiceInfo()["iyarkov.kit.Admin"]; !ok {
+		t.Errorf("Expecting the admin service to be registered")
+	}
+
+	cfg.DisableHealth = true
+	if server, err = NewServer(ctx, cfg); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if _, ok := server.GetServiceInfo()["grpc.health.v1.Health"]; ok || server.Health != nil {
+		t.Errorf("Expecting no health service")
+	}
+
+	conn, err := Dial(ctx, cfg, "localhost:0")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err = conn.Close(); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	cfg.Tls.AppKey = filepath.Join(t.TempDir(), "missing.key")
+	if _, err = NewServer(ctx, cfg); err == nil {
+		t.Errorf("Expecting tls error")
+	}
+	if _, err = Dial(ctx, cfg, "localhost:0"); err == nil {
+		t.Errorf("Expecting tls error")
+	}
+}
@@ -0,0 +1,167 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"github.com/iyarkov/kit/auth"
+	"github.com/iyarkov/kit/telemetry"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"sync"
+)
+
+// serverStream replaces the stream context so handlers see the enriched context
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func withStreamContext(stream grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return &serverStream{
+		ServerStream: stream,
+		ctx:          ctx,
+	}
+}
+
+// StreamServerContextId Server Side Stream Interceptor, see ServerContextId
+func StreamServerContextId(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, withStreamContext(stream, withIncomingContextId(stream.Context())))
+}
+
+// StreamClientContextId Client Side Stream Interceptor, see ClientContextId
+func StreamClientContextId(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	zerolog.Ctx(ctx).Debug().Msg("StreamClientContextId")
+	return streamer(withOutgoingContextId(ctx), desc, cc, method, opts...)
+}
+
+// StreamServerConnectionInfo Server Side Stream Interceptor, see ServerConnectionInfo
+func StreamServerConnectionInfo(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := withConnectionInfo(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, withStreamContext(stream, ctx))
+}
+
+// NewStreamServerAuthInterceptor Server Side Stream Interceptor, see NewServerAuthInterceptor
+func NewStreamServerAuthInterceptor(initCtx context.Context, conf *auth.Configuration) grpc.StreamServerInterceptor {
+	authenticator, err := newAuthenticator(initCtx, conf, nil)
+	if err != nil {
+		zerolog.Ctx(initCtx).Error().Err(err).Msg("stream auth interceptor: unknown roles are ignored")
+	}
+	return authenticator.interceptStream
+}
+
+// NewStreamServerPolicyInterceptor Server Side Stream Interceptor, see NewServerPolicyInterceptor
+func NewStreamServerPolicyInterceptor(initCtx context.Context, conf *PolicyConfiguration) (grpc.StreamServerInterceptor, error) {
+	policies, err := resolvePolicies(conf)
+	if err != nil {
+		return nil, err
+	}
+	zerolog.Ctx(initCtx).Debug().Msgf("stream policy interceptor initialized with %d policies", len(policies))
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(stream.Context(), policies, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}, nil
+}
+
+// StreamClientAuth Client Side Stream Interceptor, see ClientAuth
+func StreamClientAuth(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	zerolog.Ctx(ctx).Debug().Msg("StreamClientAuth")
+	ctx, err := withOutgoingToken(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return streamer(ctx, desc, cc, method, opts...)
+}
+
+// NewStreamClientAuth Client Side Stream Interceptor, see NewClientAuth
+func NewStreamClientAuth(signer auth.Signer) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		zerolog.Ctx(ctx).Debug().Msg("StreamClientAuth")
+		ctx, err := withOutgoingToken(ctx, signer)
+		if err != nil {
+			return nil, err
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// NewStreamClientRefreshAuth Client Side Stream Interceptor, see NewClientRefreshAuth
+func NewStreamClientRefreshAuth(source *auth.TokenSource) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, err := withSourceToken(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// StreamServerTrace Server Side Stream Interceptor, the span covers the whole stream
+func StreamServerTrace(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := startServerSpan(stream.Context(), info.FullMethod)
+	defer span.End()
+	return handler(srv, withStreamContext(stream, ctx))
+}
+
+// clientStream ends the span when the stream is finished: RecvMsg fails, the single response of a not server
+// streaming call is received or the context is done
+type clientStream struct {
+	grpc.ClientStream
+	span          trace.Span
+	serverStreams bool
+	once          sync.Once
+	done          chan struct{}
+}
+
+func newClientStream(ctx context.Context, stream grpc.ClientStream, desc *grpc.StreamDesc, span trace.Span) *clientStream {
+	result := clientStream{
+		ClientStream:  stream,
+		span:          span,
+		serverStreams: desc.ServerStreams,
+		done:          make(chan struct{}),
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			result.end()
+		case <-result.done:
+		}
+	}()
+	return &result
+}
+
+func (s *clientStream) end() {
+	s.once.Do(func() {
+		close(s.done)
+		s.span.End()
+	})
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil || !s.serverStreams {
+		s.end()
+	}
+	return err
+}
+
+// StreamClientTrace Client Side Stream Interceptor, the span ends when the stream is finished
+func StreamClientTrace(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	zerolog.Ctx(ctx).Debug().Msg("StreamClientTrace")
+	ctx, span := telemetry.StartSpan(ctx, fmt.Sprintf("grpc%s", method), trace.WithSpanKind(trace.SpanKindClient))
+	stream, err := streamer(withOutgoingTrace(ctx), desc, cc, method, opts...)
+	if err != nil {
+		span.End()
+		return nil, err
+	}
+	return newClientStream(ctx, stream, desc, span), nil
+}
@@ -0,0 +1,340 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/iyarkov/kit/auth"
+	"github.com/iyarkov/kit/support"
+	"github.com/iyarkov/kit/telemetry"
+	"github.com/iyarkov/kit/tls"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	received int
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testServerStream) RecvMsg(m interface{}) error {
+	s.received++
+	return nil
+}
+
+func TestStreamServerContextId(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(contextIdMeta, "ctx-1"))
+	stream := &testServerStream{ctx: ctx}
+	info := &grpc.StreamServerInfo{FullMethod: "/kit.Test/Stream"}
+
+	var contextId string
+	err := StreamServerContextId(nil, stream, info, func(srv interface{}, stream grpc.ServerStream) error {
+		contextId = support.ContextId(stream.Context())
+		return stream.RecvMsg(nil)
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if contextId != "ctx-1" {
+		t.Errorf("Handler must see the context id, got [%s]", contextId)
+	}
+	if stream.received != 1 {
+		t.Errorf("Wrapped stream must delegate to the original stream")
+	}
+}
+
+type testClientStream struct {
+	grpc.ClientStream
+	responses int
+}
+
+func (s *testClientStream) RecvMsg(m interface{}) error {
+	if s.responses == 0 {
+		return io.EOF
+	}
+	s.responses--
+	return nil
+}
+
+func (s *testClientStream) SendMsg(m interface{}) error {
+	return nil
+}
+
+type testSpan struct {
+	trace.Span
+	ended atomic.Int32
+}
+
+func (s *testSpan) End(options ...trace.SpanEndOption) {
+	s.ended.Add(1)
+}
+
+func TestStreamClientTraceEnd(t *testing.T) {
+	ctx := context.Background()
+	noop := trace.SpanFromContext(ctx)
+
+	// client streaming, the single response finishes the stream
+	span := &testSpan{Span: noop}
+	stream := newClientStream(ctx, &testClientStream{responses: 1}, &grpc.StreamDesc{ClientStreams: true}, span)
+	if err := stream.RecvMsg(nil); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if span.ended.Load() != 1 {
+		t.Errorf("Expecting the span to end after the response, ended %d times", span.ended.Load())
+	}
+
+	// server streaming, the span ends on EOF only once
+	span = &testSpan{Span: noop}
+	stream = newClientStream(ctx, &testClientStream{responses: 2}, &grpc.StreamDesc{ServerStreams: true}, span)
+	for stream.RecvMsg(nil) == nil {
+		if span.ended.Load() != 0 {
+			t.Fatalf("Span ended before the stream")
+		}
+	}
+	_ = stream.RecvMsg(nil)
+	if span.ended.Load() != 1 {
+		t.Errorf("Expecting the span to end once, ended %d times", span.ended.Load())
+	}
+
+	// abandoned stream
+	span = &testSpan{Span: noop}
+	cancelCtx, cancel := context.WithCancel(ctx)
+	newClientStream(cancelCtx, &testClientStream{}, &grpc.StreamDesc{ServerStreams: true}, span)
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for span.ended.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if span.ended.Load() != 1 {
+		t.Errorf("Expecting the span to end when the context is done")
+	}
+}
+
+func withTestKeyring(t *testing.T, secret byte) *auth.HmacKeyring {
+	keyring := auth.NewHmacKeyring()
+	if err := keyring.AddKey(1, bytes.Repeat([]byte{secret}, 32)); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := keyring.Rotate(1); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	auth.SetSigner(keyring)
+	auth.SetVerifier(keyring)
+	t.Cleanup(func() {
+		auth.SetSigner(nil)
+		auth.SetVerifier(nil)
+	})
+	return keyring
+}
+
+func testToken(t *testing.T, role auth.Role) string {
+	token := auth.Token{AccountId: 4, Role: role, ExpiresAt: time.Now().Add(time.Hour)}
+	encoded, err := token.WriteToString()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	return encoded
+}
+
+func TestStreamServerAuth(t *testing.T) {
+	// signed with a different secret of the same key id
+	withTestKeyring(t, 2)
+	forged := testToken(t, auth.Admin)
+	withTestKeyring(t, 1)
+	interceptor := NewStreamServerAuthInterceptor(context.Background(), &auth.Configuration{
+		TrustedPeers:   []string{"gateway"},
+		UntrustedRoles: []string{"user"},
+	})
+	info := &grpc.StreamServerInfo{FullMethod: "/kit.Test/Stream"}
+
+	type spec struct {
+		name     string
+		peer     string
+		token    string
+		expected codes.Code
+		trusted  bool
+	}
+	suite := []spec{
+		{"trusted admin", "gateway", testToken(t, auth.Admin), codes.OK, true},
+		{"untrusted user", "partner", testToken(t, auth.User), codes.OK, false},
+		{"untrusted admin", "partner", testToken(t, auth.Admin), codes.PermissionDenied, false},
+		{"forged", "gateway", forged, codes.Unauthenticated, false},
+	}
+	for _, test := range suite {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), &connectionInfoCtxKey{}, tls.ConnectionInfo{Peer: test.peer})
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(authTokenMeta, test.token))
+			called := false
+			err := interceptor(nil, &testServerStream{ctx: ctx}, info, func(srv interface{}, stream grpc.ServerStream) error {
+				called = true
+				token := auth.AuthToken(stream.Context())
+				if token.AccountId != 4 {
+					t.Errorf("Handler must see the token, got %v", token)
+				}
+				if IsTrustedPeer(stream.Context()) != test.trusted {
+					t.Errorf("Expecting trusted %t", test.trusted)
+				}
+				return nil
+			})
+			if status.Code(err) != test.expected {
+				t.Errorf("Expecting %s, got %v", test.expected, err)
+			}
+			if called != (test.expected == codes.OK) {
+				t.Errorf("Handler called %t, expecting %s", called, test.expected)
+			}
+		})
+	}
+}
+
+func TestStreamServerPolicy(t *testing.T) {
+	interceptor, err := NewStreamServerPolicyInterceptor(context.Background(), &PolicyConfiguration{
+		Methods: map[string]Policy{
+			"/kit.Test/Public": {Anonymous: true},
+			"/kit.Test/Admin":  {Roles: []string{"admin"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	withRole := func(role auth.Role) context.Context {
+		return auth.WithToken(context.Background(), &auth.Token{AccountId: 4, Role: role, ExpiresAt: time.Now().Add(time.Hour)})
+	}
+
+	type spec struct {
+		name     string
+		ctx      context.Context
+		method   string
+		expected codes.Code
+	}
+	suite := []spec{
+		{"anonymous public", context.Background(), "/kit.Test/Public", codes.OK},
+		{"anonymous admin", context.Background(), "/kit.Test/Admin", codes.Unauthenticated},
+		{"user admin", withRole(auth.User), "/kit.Test/Admin", codes.PermissionDenied},
+		{"admin", withRole(auth.Admin), "/kit.Test/Admin", codes.OK},
+		{"no policy", withRole(auth.Admin), "/kit.Test/Other", codes.PermissionDenied},
+	}
+	for _, test := range suite {
+		t.Run(test.name, func(t *testing.T) {
+			called := false
+			err := interceptor(nil, &testServerStream{ctx: test.ctx}, &grpc.StreamServerInfo{FullMethod: test.method}, func(srv interface{}, stream grpc.ServerStream) error {
+				called = true
+				return nil
+			})
+			if status.Code(err) != test.expected {
+				t.Errorf("Expecting %s, got %v", test.expected, err)
+			}
+			if called != (test.expected == codes.OK) {
+				t.Errorf("Handler called %t, expecting %s", called, test.expected)
+			}
+		})
+	}
+}
+
+// testStreamer captures the outgoing metadata
+func testStreamer(outgoing *metadata.MD) grpc.Streamer {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		*outgoing, _ = metadata.FromOutgoingContext(ctx)
+		return &testClientStream{}, nil
+	}
+}
+
+func TestStreamClientAuth(t *testing.T) {
+	withTestKeyring(t, 1)
+	desc := &grpc.StreamDesc{ServerStreams: true}
+	var outgoing metadata.MD
+
+	ctx := auth.WithToken(context.Background(), &auth.Token{AccountId: 4, Role: auth.User, ExpiresAt: time.Now().Add(time.Hour)})
+	if _, err := StreamClientAuth(ctx, desc, nil, "/kit.Test/Stream", testStreamer(&outgoing)); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(outgoing.Get(authTokenMeta)) != 1 {
+		t.Errorf("Expecting the token to be sent, got %v", outgoing)
+	}
+
+	outgoing = nil
+	if _, err := StreamClientAuth(context.Background(), desc, nil, "/kit.Test/Stream", testStreamer(&outgoing)); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(outgoing.Get(authTokenMeta)) != 0 {
+		t.Errorf("Expecting no token for an anonymous call, got %v", outgoing)
+	}
+
+	// the token can not be signed
+	auth.SetSigner(nil)
+	outgoing = nil
+	stream, err := StreamClientAuth(ctx, desc, nil, "/kit.Test/Stream", testStreamer(&outgoing))
+	if status.Code(err) != codes.Unauthenticated || stream != nil || outgoing != nil {
+		t.Errorf("Expecting Unauthenticated without calling the server, got %v", err)
+	}
+}
+
+func TestStreamClientRefreshAuth(t *testing.T) {
+	desc := &grpc.StreamDesc{ServerStreams: true}
+	var outgoing metadata.MD
+	refreshed := auth.TokenPair{AccessToken: "fresh", AccessExpiresAt: time.Now().Add(time.Hour)}
+	source := auth.NewTokenSource(auth.TokenPair{AccessToken: "stale"}, func(ctx context.Context, refreshToken string) (auth.TokenPair, error) {
+		return refreshed, nil
+	}, time.Second)
+	interceptor := NewStreamClientRefreshAuth(source)
+	if _, err := interceptor(context.Background(), desc, nil, "/kit.Test/Stream", testStreamer(&outgoing)); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if tokens := outgoing.Get(authTokenMeta); len(tokens) != 1 || tokens[0] != "fresh" {
+		t.Errorf("Expecting the refreshed token, got %v", tokens)
+	}
+
+	failing := auth.NewTokenSource(auth.TokenPair{AccessToken: "stale"}, func(ctx context.Context, refreshToken string) (auth.TokenPair, error) {
+		return auth.TokenPair{}, errors.New("issuer is down")
+	}, time.Second)
+	outgoing = nil
+	stream, err := NewStreamClientRefreshAuth(failing)(context.Background(), desc, nil, "/kit.Test/Stream", testStreamer(&outgoing))
+	if status.Code(err) != codes.Unauthenticated || stream != nil || outgoing != nil {
+		t.Errorf("Expecting Unauthenticated without calling the server, got %v", err)
+	}
+}
+
+func TestStreamTrace(t *testing.T) {
+	telemetry.InitTelemetry(context.Background(), &telemetry.Configuration{})
+	traceId := "4bf92f3577b34da6a3ce929d0e0e4736"
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("traceparent", "00-"+traceId+"-00f067aa0ba902b7-01"))
+	desc := &grpc.StreamDesc{ServerStreams: true}
+
+	var outgoing metadata.MD
+	err := StreamServerTrace(nil, &testServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/kit.Test/Stream"}, func(srv interface{}, stream grpc.ServerStream) error {
+		if trace.SpanContextFromContext(stream.Context()).TraceID().String() != traceId {
+			t.Errorf("Server span must continue the caller trace, got %s", trace.SpanContextFromContext(stream.Context()).TraceID())
+		}
+		clientStream, err := StreamClientTrace(stream.Context(), desc, nil, "/kit.Test/Next", testStreamer(&outgoing))
+		if err != nil {
+			return err
+		}
+		return clientStream.RecvMsg(nil)
+	})
+	if err != io.EOF {
+		t.Fatalf("Expecting the stream error to pass through, got %v", err)
+	}
+	if traceparent := outgoing.Get("traceparent"); len(traceparent) != 1 || !strings.Contains(traceparent[0], traceId) {
+		t.Errorf("Client must pass the trace to the next service, got %v", traceparent)
+	}
+
+	failed := status.Error(codes.Unavailable, "down")
+	stream, err := StreamClientTrace(context.Background(), desc, nil, "/kit.Test/Next", func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return nil, failed
+	})
+	if err != failed || stream != nil {
+		t.Errorf("Expecting the streamer error, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package grpc
+
+import (
+	"context"
+	"github.com/iyarkov/kit/validate"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// ServerValidation Server Side Interceptor, validates the request against the iyarkov.kit.rules field options,
+// invalid requests are rejected with INVALID_ARGUMENT and a BadRequest detail listing the field violations
+func ServerValidation(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, toStatusError(ctx, err)
+	}
+	return handler(ctx, req)
+}
+
+func validateRequest(req interface{}) error {
+	if message, ok := req.(proto.Message); ok {
+		return validate.Message(message)
+	}
+	return nil
+}
+
+// validatingServerStream validates each received message
+type validatingServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatingServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if err := validateRequest(m); err != nil {
+		return toStatusError(s.Context(), err)
+	}
+	return nil
+}
+
+// StreamServerValidation Server Side Stream Interceptor, see ServerValidation. The handler gets the error from
+// RecvMsg
+func StreamServerValidation(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &validatingServerStream{ServerStream: stream})
+}
@@ -0,0 +1,42 @@
+package grpc
+
+import (
+	"context"
+	"github.com/iyarkov/kit/support/protobuf"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestServerValidation(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/kit.Objects/Get"}
+	called := false
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return req, nil
+	}
+	if _, err := ServerValidation(context.Background(), &protobuf.ObjectRequest{Id: "1"}, info, handler); err != nil || !called {
+		t.Fatalf("Valid request must pass, got %v", err)
+	}
+
+	called = false
+	_, err := ServerValidation(context.Background(), &protobuf.ObjectRequest{}, info, handler)
+	if called {
+		t.Errorf("Handler must not be called")
+	}
+	grpcStatus := status.Convert(err)
+	if grpcStatus.Code() != codes.InvalidArgument {
+		t.Fatalf("Expecting InvalidArgument, got %v", err)
+	}
+	var badRequest *errdetails.BadRequest
+	for _, detail := range grpcStatus.Details() {
+		if typed, ok := detail.(*errdetails.BadRequest); ok {
+			badRequest = typed
+		}
+	}
+	if badRequest == nil || len(badRequest.FieldViolations) != 1 || badRequest.FieldViolations[0].Field != "id" {
+		t.Errorf("Expecting the id violation, got %v", badRequest)
+	}
+}
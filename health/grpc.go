@@ -0,0 +1,74 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+// grpcHealth grpc.health.v1 service over the registry. The empty service name is the overall readiness, the other
+// names are the registered checks
+type grpcHealth struct {
+	healthpb.UnimplementedHealthServer
+	registry *Registry
+}
+
+// GrpcServer returns the grpc.health.v1 implementation, register it with healthpb.RegisterHealthServer
+func (r *Registry) GrpcServer() healthpb.HealthServer {
+	return &grpcHealth{
+		registry: r,
+	}
+}
+
+func (h *grpcHealth) status(ctx context.Context, service string) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	var err error
+	if service == "" {
+		err = h.registry.Ready(ctx)
+	} else {
+		err = h.registry.Check(ctx, service)
+		if errors.Is(err, ErrorUnknownCheck) {
+			return healthpb.HealthCheckResponse_SERVICE_UNKNOWN, err
+		}
+		if err == nil && h.registry.IsShuttingDown() {
+			err = ErrorShuttingDown
+		}
+	}
+	if err != nil {
+		return healthpb.HealthCheckResponse_NOT_SERVING, nil
+	}
+	return healthpb.HealthCheckResponse_SERVING, nil
+}
+
+func (h *grpcHealth) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	servingStatus, err := h.status(ctx, req.Service)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+	return &healthpb.HealthCheckResponse{Status: servingStatus}, nil
+}
+
+// Watch sends the status when it changes, the status is checked once per cache ttl
+func (h *grpcHealth) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+	ticker := time.NewTicker(h.registry.cacheTtl)
+	defer ticker.Stop()
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		// unknown services are reported as SERVICE_UNKNOWN, they may be registered later
+		servingStatus, _ := h.status(ctx, req.Service)
+		if servingStatus != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: servingStatus}); err != nil {
+				return err
+			}
+			last = servingStatus
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/iyarkov/kit/support"
+	"github.com/rs/zerolog"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultTimeout  = time.Second
+	defaultCacheTtl = 5 * time.Second
+)
+
+var ErrorShuttingDown = errors.New("shutting down")
+var ErrorCheckTimeout = errors.New("check timeout")
+var ErrorUnknownCheck = errors.New("unknown check")
+
+// Checker returns nil when the component is ready
+type Checker func(ctx context.Context) error
+
+// Configuration Address of the HTTP /healthz and /readyz endpoints, empty disables HTTP. Each check runs at most
+// once per CacheTtl and fails after Timeout
+type Configuration struct {
+	Address  string
+	Timeout  time.Duration
+	CacheTtl time.Duration
+}
+
+type check struct {
+	name    string
+	checker Checker
+
+	mu        sync.Mutex
+	result    error
+	checkedAt time.Time
+}
+
+// Registry components register their checkers, the service is ready when all the checks pass. Readiness flips to
+// not ready on SIGTERM
+type Registry struct {
+	timeout  time.Duration
+	cacheTtl time.Duration
+	address  string
+
+	mu           sync.RWMutex
+	checks       map[string]*check
+	shuttingDown atomic.Bool
+	httpServer   *http.Server
+}
+
+func NewRegistry(cfg *Configuration) *Registry {
+	registry := Registry{
+		timeout:  cfg.Timeout,
+		cacheTtl: cfg.CacheTtl,
+		address:  cfg.Address,
+		checks:   make(map[string]*check),
+	}
+	if registry.timeout <= 0 {
+		registry.timeout = defaultTimeout
+	}
+	if registry.cacheTtl <= 0 {
+		registry.cacheTtl = defaultCacheTtl
+	}
+	support.OnSigTerm(func(ctx context.Context, signal os.Signal) {
+		registry.Shutdown()
+	})
+	return &registry
+}
+
+// Register adds the named check, the name is also the gRPC health service name of the check
+func (r *Registry) Register(name string, checker Checker) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.checks[name]; ok {
+		return fmt.Errorf("check [%s] already registered", name)
+	}
+	r.checks[name] = &check{
+		name:    name,
+		checker: checker,
+	}
+	return nil
+}
+
+// Shutdown marks the service as not ready, call it before the shutdown begins so the load balancers stop sending
+// new requests
+func (r *Registry) Shutdown() {
+	r.shuttingDown.Store(true)
+}
+
+func (r *Registry) IsShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// Check runs the named check, returns ErrorUnknownCheck if there is no such check
+func (r *Registry) Check(ctx context.Context, name string) error {
+	r.mu.RLock()
+	c, ok := r.checks[name]
+	r.mu.RUnlock()
+	if !ok {
+		return ErrorUnknownCheck
+	}
+	return r.run(ctx, c)
+}
+
+// CheckAll runs all the checks in parallel, the result has an entry for each check
+func (r *Registry) CheckAll(ctx context.Context) map[string]error {
+	r.mu.RLock()
+	checks := make([]*check, 0, len(r.checks))
+	for _, c := range r.checks {
+		checks = append(checks, c)
+	}
+	r.mu.RUnlock()
+
+	results := make(map[string]error, len(checks))
+	var resultsMu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c *check) {
+			defer wg.Done()
+			err := r.run(ctx, c)
+			resultsMu.Lock()
+			results[c.name] = err
+			resultsMu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+	return results
+}
+
+// Ready returns nil when the service is not shutting down and all the checks pass
+func (r *Registry) Ready(ctx context.Context) error {
+	if r.IsShuttingDown() {
+		return ErrorShuttingDown
+	}
+	results := r.CheckAll(ctx)
+	names := make([]string, 0, len(results))
+	for name, err := range results {
+		if err != nil {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		sort.Strings(names)
+		return fmt.Errorf("checks failed: %v", names)
+	}
+	return nil
+}
+
+func (r *Registry) run(ctx context.Context, c *check) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.checkedAt.IsZero() && time.Since(c.checkedAt) < r.cacheTtl {
+		return c.result
+	}
+
+	// the result is cached, so the check runs with its own timeout whatever happens to the caller context
+	ctx, cancel := context.WithTimeout(detached{ctx}, r.timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				done <- fmt.Errorf("check panicked: %v", rec)
+			}
+		}()
+		done <- c.checker(ctx)
+	}()
+	select {
+	case c.result = <-done:
+	case <-ctx.Done():
+		c.result = ErrorCheckTimeout
+	}
+	c.checkedAt = time.Now()
+	if c.result != nil {
+		zerolog.Ctx(ctx).Warn().Err(c.result).Msgf("health check %s failed", c.name)
+	}
+	return c.result
+}
+
+// detached keeps the values of the parent context, the logger and the context id, without its cancellation
+type detached struct {
+	context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detached) Done() <-chan struct{} {
+	return nil
+}
+
+func (detached) Err() error {
+	return nil
+}
+
+type readyResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// Handler serves /healthz, the process is alive, and /readyz, the service is ready, 503 otherwise
+func (r *Registry) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, req *http.Request) {
+		response := readyResponse{
+			Status: "ready",
+			Checks: make(map[string]string),
+		}
+		for name, err := range r.CheckAll(req.Context()) {
+			if err != nil {
+				response.Status = "not ready"
+				response.Checks[name] = err.Error()
+			} else {
+				response.Checks[name] = "ok"
+			}
+		}
+		if r.IsShuttingDown() {
+			response.Status = ErrorShuttingDown.Error()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if response.Status == "ready" {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			zerolog.Ctx(req.Context()).Warn().Err(err).Msg("failed to write readiness response")
+		}
+	})
+	return mux
+}
+
+// ListenAndServe serves the HTTP endpoints until Close, it does nothing when the Address is empty
+func (r *Registry) ListenAndServe(ctx context.Context) error {
+	if r.address == "" {
+		return nil
+	}
+	r.mu.Lock()
+	r.httpServer = &http.Server{
+		Addr:    r.address,
+		Handler: r.Handler(),
+	}
+	server := r.httpServer
+	r.mu.Unlock()
+	zerolog.Ctx(ctx).Info().Msgf("health HTTP server listening on %s", r.address)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("health HTTP server failed: %w", err)
+	}
+	return nil
+}
+
+// Close stops the HTTP endpoints, keep them running while the service drains so the orchestrator sees it not ready
+func (r *Registry) Close(ctx context.Context) {
+	r.Shutdown()
+	r.mu.RLock()
+	server := r.httpServer
+	r.mu.RUnlock()
+	if server == nil {
+		return
+	}
+	if err := server.Shutdown(ctx); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("health HTTP server shutdown failed")
+	}
+}
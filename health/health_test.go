@@ -0,0 +1,143 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry(&Configuration{CacheTtl: time.Hour, Timeout: 10 * time.Millisecond})
+	var calls atomic.Int32
+	var failure atomic.Pointer[error]
+	if err := registry.Register("db", func(ctx context.Context) error {
+		calls.Add(1)
+		if err := failure.Load(); err != nil {
+			return *err
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := registry.Register("db", func(ctx context.Context) error { return nil }); err == nil {
+		t.Errorf("Expecting duplicate check error")
+	}
+	ctx := context.Background()
+
+	if err := registry.Ready(ctx); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	dbDown := errors.New("db is down")
+	failure.Store(&dbDown)
+	if err := registry.Ready(ctx); err != nil {
+		t.Errorf("Expecting cached result, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expecting 1 call, got %d", calls.Load())
+	}
+
+	if err := registry.Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(time.Millisecond)
+		return nil
+	}); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := registry.Check(ctx, "slow"); !errors.Is(err, ErrorCheckTimeout) {
+		t.Errorf("Expecting timeout, got %v", err)
+	}
+	if err := registry.Check(ctx, "cache"); !errors.Is(err, ErrorUnknownCheck) {
+		t.Errorf("Expecting unknown check, got %v", err)
+	}
+	if err := registry.Ready(ctx); err == nil {
+		t.Errorf("Expecting failed checks")
+	}
+}
+
+func TestRegistryCancelledCaller(t *testing.T) {
+	registry := NewRegistry(&Configuration{CacheTtl: time.Hour, Timeout: time.Second})
+	if err := registry.Register("db", func(ctx context.Context) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		time.Sleep(time.Millisecond)
+		return nil
+	}); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := registry.Check(ctx, "db"); err != nil {
+		t.Errorf("Expecting the check to run on its own context, got %v", err)
+	}
+	if err := registry.Check(context.Background(), "db"); err != nil {
+		t.Errorf("Expecting the cached result to pass, got %v", err)
+	}
+}
+
+func TestGrpcHealth(t *testing.T) {
+	registry := NewRegistry(&Configuration{})
+	if err := registry.Register("db", func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	server := registry.GrpcServer()
+	ctx := context.Background()
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		response, err := server.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		return response.Status
+	}
+	if status := check(""); status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Expecting SERVING, got %s", status)
+	}
+	if status := check("db"); status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Expecting SERVING, got %s", status)
+	}
+	if status := check("nats"); status != healthpb.HealthCheckResponse_SERVICE_UNKNOWN {
+		t.Errorf("Expecting SERVICE_UNKNOWN, got %s", status)
+	}
+
+	registry.Shutdown()
+	if status := check(""); status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Expecting NOT_SERVING after shutdown, got %s", status)
+	}
+	if status := check("db"); status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Expecting NOT_SERVING after shutdown, got %s", status)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	registry := NewRegistry(&Configuration{})
+	if err := registry.Register("nats", func(ctx context.Context) error { return errors.New("disconnected") }); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	handler := registry.Handler()
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expecting 200, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expecting 503, got %d", recorder.Code)
+	}
+	var response readyResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if response.Checks["nats"] != "disconnected" {
+		t.Errorf("Expecting the check error, got %v", response.Checks)
+	}
+}
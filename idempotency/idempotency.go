@@ -0,0 +1,117 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const memorySweepInterval = time.Minute
+
+var ErrorInFlight = errors.New("request in flight")
+var ErrorRequestMismatch = errors.New("idempotency key reused with a different request")
+
+// Key identifies a request, the same key of different accounts or methods is a different request
+type Key struct {
+	AccountId uint64
+	Method    string
+	Key       string
+}
+
+// Record state of a request, Response is set once the request is completed
+type Record struct {
+	RequestHash []byte
+	Response    []byte
+	Completed   bool
+}
+
+// Store persists the requests state. Reserve claims the key for lockTimeout and returns nil, or returns the existing
+// record when the key is claimed or completed and not expired yet. Complete stores the response for ttl, Release
+// drops the claim so the request can be retried
+type Store interface {
+	Reserve(ctx context.Context, key Key, requestHash []byte, lockTimeout time.Duration) (*Record, error)
+	Complete(ctx context.Context, key Key, response []byte, ttl time.Duration) error
+	Release(ctx context.Context, key Key) error
+}
+
+// Check returns the error to reply with on a duplicate request, nil if the stored response can be replayed
+func (r *Record) Check(requestHash []byte) error {
+	if !bytes.Equal(r.RequestHash, requestHash) {
+		return ErrorRequestMismatch
+	}
+	if !r.Completed {
+		return ErrorInFlight
+	}
+	return nil
+}
+
+type memoryRecord struct {
+	Record
+	expiresAt time.Time
+}
+
+// MemoryStore keeps the records in memory, suitable for tests and single instance services
+type MemoryStore struct {
+	mu        sync.Mutex
+	records   map[Key]*memoryRecord
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: make(map[Key]*memoryRecord),
+	}
+}
+
+func (s *MemoryStore) Reserve(_ context.Context, key Key, requestHash []byte, lockTimeout time.Duration) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweep(now)
+	if record, ok := s.records[key]; ok && now.Before(record.expiresAt) {
+		result := record.Record
+		return &result, nil
+	}
+	s.records[key] = &memoryRecord{
+		Record:    Record{RequestHash: requestHash},
+		expiresAt: now.Add(lockTimeout),
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, key Key, response []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[key]
+	if !ok {
+		// the claim expired and was swept, the next request runs again
+		return nil
+	}
+	record.Response = response
+	record.Completed = true
+	record.expiresAt = time.Now().Add(ttl)
+	return nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[key]; ok && !record.Completed {
+		delete(s.records, key)
+	}
+	return nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < memorySweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, record := range s.records {
+		if !now.Before(record.expiresAt) {
+			delete(s.records, key)
+		}
+	}
+}
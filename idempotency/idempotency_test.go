@@ -0,0 +1,52 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	key := Key{AccountId: 1, Method: "/kit.Orders/Create", Key: "a"}
+	hash := []byte{1}
+
+	record, err := store.Reserve(ctx, key, hash, time.Minute)
+	if err != nil || record != nil {
+		t.Fatalf("Expecting the key reserved, got %v, %v", record, err)
+	}
+	if record, _ = store.Reserve(ctx, key, hash, time.Minute); record == nil || !errors.Is(record.Check(hash), ErrorInFlight) {
+		t.Errorf("Expecting the request in flight, got %v", record)
+	}
+	if err = store.Complete(ctx, key, []byte("response"), time.Minute); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	record, _ = store.Reserve(ctx, key, hash, time.Minute)
+	if record == nil || record.Check(hash) != nil || string(record.Response) != "response" {
+		t.Errorf("Expecting the stored response, got %v", record)
+	}
+	if !errors.Is(record.Check([]byte{2}), ErrorRequestMismatch) {
+		t.Errorf("Expecting a request mismatch")
+	}
+	if err = store.Release(ctx, key); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if record, _ = store.Reserve(ctx, key, hash, time.Minute); record == nil {
+		t.Errorf("Completed records must not be released")
+	}
+
+	other := Key{AccountId: 1, Method: "/kit.Orders/Create", Key: "b"}
+	_, _ = store.Reserve(ctx, other, hash, time.Minute)
+	_ = store.Release(ctx, other)
+	if record, _ = store.Reserve(ctx, other, hash, time.Minute); record != nil {
+		t.Errorf("Released key must be reserved again, got %v", record)
+	}
+
+	expired := Key{AccountId: 1, Method: "/kit.Orders/Create", Key: "c"}
+	_, _ = store.Reserve(ctx, expired, hash, -time.Second)
+	if record, _ = store.Reserve(ctx, expired, hash, time.Minute); record != nil {
+		t.Errorf("Expired claim must be taken over, got %v", record)
+	}
+}
@@ -2,6 +2,8 @@ package logger
 
 import (
 	"context"
+	"fmt"
+	"github.com/iyarkov/kit/config"
 	"github.com/iyarkov/kit/support"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -11,9 +13,10 @@ import (
 
 type Mode int
 
+// Configuration Level can be reloaded, apply it with Reload or Subscribe
 type Configuration struct {
 	Mode  string
-	Level string
+	Level string `reload:"true"`
 }
 
 func InitLogger(config *Configuration) {
@@ -43,8 +46,7 @@ func InitLogger(config *Configuration) {
 			Str("version", support.AppManifest.Version).
 			Str("app", support.AppManifest.Name).
 			Str("namespace", support.AppManifest.Namespace).
-			Logger().
-			Level(level)
+			Logger()
 		zerolog.DefaultContextLogger = &log.Logger
 	} else {
 		log.Logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.StampMicro}).
@@ -52,14 +54,47 @@ func InitLogger(config *Configuration) {
 			Timestamp().
 			Caller().
 			Stack().
-			Logger().
-			Level(level)
+			Logger()
 
 		zerolog.DefaultContextLogger = &log.Logger
 	}
+	// the level is global, so it can be changed at runtime with SetLevel
+	zerolog.SetGlobalLevel(level)
 	log.Info().Msg("logger system initialized")
 }
 
+// Level returns the current log level
+func Level() string {
+	return zerolog.GlobalLevel().String()
+}
+
+// SetLevel changes the log level of all the loggers
+func SetLevel(name string) error {
+	level, err := zerolog.ParseLevel(name)
+	if err != nil || level == zerolog.NoLevel {
+		return fmt.Errorf("unknown log level [%s]", name)
+	}
+	zerolog.SetGlobalLevel(level)
+	return nil
+}
+
+// Reload applies the reloadable configuration, the Mode requires a restart
+func Reload(config *Configuration) error {
+	if config.Level == "" {
+		return SetLevel(zerolog.InfoLevel.String())
+	}
+	return SetLevel(config.Level)
+}
+
+// Subscribe applies the logger section of the configuration reloaded by the watcher
+func Subscribe[T any](watcher *config.Watcher[T], section func(conf *T) *Configuration) {
+	watcher.Subscribe(func(ctx context.Context, event *config.Event[T]) {
+		if err := Reload(section(event.Config)); err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msg("logger reload failed")
+		}
+	})
+}
+
 func WithLogger(ctx context.Context) context.Context {
 	idx := support.ContextId(ctx)
 	if idx != "" {
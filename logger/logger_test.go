@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"context"
+	"github.com/iyarkov/kit/config"
+	"github.com/rs/zerolog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type appConfig struct {
+	Logger Configuration
+}
+
+func TestSubscribe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(path, []byte("logger:\n  level: info\n"), 0600); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	previous, previousLevel := os.Args, zerolog.GlobalLevel()
+	os.Args = []string{"app", "-f" + path}
+	defer func() {
+		os.Args = previous
+		zerolog.SetGlobalLevel(previousLevel)
+	}()
+
+	var conf appConfig
+	if err := config.Read(&conf); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	watcher := config.NewWatcher(&conf, &config.WatcherConfiguration{})
+	Subscribe(watcher, func(conf *appConfig) *Configuration {
+		return &conf.Logger
+	})
+	if err := os.WriteFile(path, []byte("logger:\n  level: warn\n"), 0600); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := watcher.Reload(context.Background()); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if Level() != "warn" {
+		t.Errorf("Expecting the reloaded level warn, got %s", Level())
+	}
+}
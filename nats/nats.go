@@ -5,10 +5,16 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/iyarkov/kit/support"
+	"github.com/iyarkov/kit/telemetry"
 	natsio "github.com/nats-io/nats.go"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var contextIdHeader = "contextId"
+
 type Configuration struct {
 	Host     string
 	Port     uint16
@@ -83,6 +89,61 @@ func (m *Messenger) Start(ctx context.Context, cfg *Configuration) error {
 	return nil
 }
 
+// Publish sends the message to the stream, the context id and the trace context are passed in the headers
+func (m *Messenger) Publish(ctx context.Context, subject string, data []byte) error {
+	msg := natsio.NewMsg(subject)
+	msg.Data = data
+	ctx, span := telemetry.StartSpan(ctx, fmt.Sprintf("nats/%s", subject), trace.WithSpanKind(trace.SpanKindProducer))
+	defer span.End()
+	InjectHeader(ctx, msg)
+	if _, err := m.jet.PublishMsg(msg, natsio.Context(ctx)); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("publish to %s failed: %w", subject, err)
+	}
+	return nil
+}
+
+// InjectHeader sets the context id and the trace context headers of the message
+func InjectHeader(ctx context.Context, msg *natsio.Msg) {
+	if msg.Header == nil {
+		msg.Header = natsio.Header{}
+	}
+	if contextId := support.ContextId(ctx); contextId != "" {
+		msg.Header.Set(contextIdHeader, contextId)
+	}
+	telemetry.Inject(ctx, headerCarrier(msg.Header))
+}
+
+// headerCarrier propagation.TextMapCarrier over the NATS message headers
+type headerCarrier natsio.Header
+
+func (c headerCarrier) Get(key string) string {
+	return natsio.Header(c).Get(key)
+}
+
+func (c headerCarrier) Set(key string, value string) {
+	natsio.Header(c).Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Check health checker of the NATS connection
+func (m *Messenger) Check(ctx context.Context) error {
+	if m.conn == nil {
+		return fmt.Errorf("messenger is not started")
+	}
+	if status := m.conn.Status(); status != natsio.CONNECTED {
+		return fmt.Errorf("nats connection is %s", status)
+	}
+	return nil
+}
+
 func (m *Messenger) Stop(ctx context.Context) {
 	log := zerolog.Ctx(ctx)
 
@@ -109,11 +170,20 @@ func (m *Messenger) worker(id int, inputChan chan *natsio.Msg) {
 	closeChan := make(chan context.Context, 1)
 	m.workers = append(m.workers, closeChan)
 
-	handleSafe := func(ctx context.Context, msg *natsio.Msg, handler MessageHandler) error {
+	handleSafe := func(ctx context.Context, msg *natsio.Msg, handler MessageHandler) (err error) {
+		ctx, span := telemetry.StartSpan(ctx, fmt.Sprintf("nats/%s", msg.Subject), trace.WithSpanKind(trace.SpanKindConsumer))
+		span.SetAttributes(attribute.String("contextId", support.ContextId(ctx)))
+		defer func() {
+			if err != nil {
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
 		defer func() {
 			log := zerolog.Ctx(ctx)
 			if r := recover(); r != nil {
 				log.Error().Any("recovered", r).Msg("message handler panicked")
+				span.SetStatus(codes.Error, "handler panicked")
 			}
 		}()
 		return handler(ctx, msg)
@@ -126,11 +196,13 @@ func (m *Messenger) worker(id int, inputChan chan *natsio.Msg) {
 					// channel closed - do nothing
 					continue
 				}
-				contextId := msg.Header.Get("contextId")
+				contextId := msg.Header.Get(contextIdHeader)
 				if contextId == "" {
 					contextId = uuid.New().String()
 				}
-				ctx := support.WithContextId(context.Background(), contextId)
+				// the handler span continues the publisher trace
+				ctx := telemetry.Extract(context.Background(), headerCarrier(msg.Header))
+				ctx = support.WithContextId(ctx, contextId)
 				log := zerolog.DefaultContextLogger.With().
 					Str("contextId", contextId).
 					Int("msgWorker", id).
@@ -1,5 +1,7 @@
 package protobuf
 
 //go:generate protoc --go_out=.. --go_opt=module=github.com/iyarkov/kit iyarkov/kit/hlc.proto
+//go:generate protoc --go_out=.. --go_opt=module=github.com/iyarkov/kit iyarkov/kit/validate.proto
 //go:generate protoc --go_out=.. --go_opt=module=github.com/iyarkov/kit iyarkov/kit/object.proto
 //go:generate protoc --go_out=.. --go_opt=module=github.com/iyarkov/kit iyarkov/kit/page_request.proto
+//go:generate protoc --go_out=.. --go_opt=module=github.com/iyarkov/kit --go-grpc_out=.. --go-grpc_opt=module=github.com/iyarkov/kit iyarkov/kit/admin.proto
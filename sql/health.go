@@ -0,0 +1,29 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// PingChecker health checker of the connection pool
+func PingChecker(db *sql.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if err := db.PingContext(ctx); err != nil {
+			return fmt.Errorf("ping failed: %w", err)
+		}
+		return nil
+	}
+}
+
+// ValidateChecker validates the schema once, the checker reports the validation result
+func ValidateChecker(ctx context.Context, db *sql.DB, expected Schema, strict bool) func(ctx context.Context) error {
+	violations, err := Validate(ctx, db, expected, strict)
+	if err == nil && len(violations) > 0 {
+		err = fmt.Errorf("schema validation failed: %s", strings.Join(violations, "; "))
+	}
+	return func(ctx context.Context) error {
+		return err
+	}
+}
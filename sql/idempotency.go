@@ -0,0 +1,136 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/iyarkov/kit/idempotency"
+	"time"
+)
+
+var queryCreateIdempotencyTable = `
+CREATE TABLE idempotency_key (
+	account_id BIGINT NOT NULL,
+	method VARCHAR(255) NOT NULL,
+	key VARCHAR(255) NOT NULL,
+	request_hash BYTEA NOT NULL,
+	response BYTEA,
+	completed BOOLEAN NOT NULL,
+	expires_at TIMESTAMP(3) WITHOUT TIME ZONE NOT NULL,
+	PRIMARY KEY (account_id, method, key)
+)`
+
+// the claim is taken over when the existing record is expired
+var queryReserveIdempotencyKey = `
+INSERT INTO idempotency_key(account_id, method, key, request_hash, completed, expires_at) VALUES($1, $2, $3, $4, false, $5)
+ON CONFLICT (account_id, method, key) DO UPDATE SET request_hash = EXCLUDED.request_hash, response = NULL,
+	completed = false, expires_at = EXCLUDED.expires_at
+WHERE idempotency_key.expires_at <= $6
+RETURNING account_id`
+
+var queryLoadIdempotencyKey = `
+SELECT request_hash, response, completed FROM idempotency_key WHERE account_id = $1 AND method = $2 AND key = $3`
+
+var queryCompleteIdempotencyKey = `
+UPDATE idempotency_key SET response = $4, completed = true, expires_at = $5
+WHERE account_id = $1 AND method = $2 AND key = $3`
+
+var queryReleaseIdempotencyKey = `
+DELETE FROM idempotency_key WHERE account_id = $1 AND method = $2 AND key = $3 AND completed = false`
+
+var queryDeleteExpiredIdempotencyKeys = "DELETE FROM idempotency_key WHERE expires_at <= $1"
+
+// IdempotencyChangeset creates the idempotency_key table, add it to the application changeset before calling Update
+var IdempotencyChangeset = []Change{
+	{
+		Version:  "kit.idempotency.1",
+		Commands: []string{queryCreateIdempotencyTable},
+	},
+}
+
+// IdempotencyTable expected idempotency_key table for Validate
+var IdempotencyTable = Table{
+	Columns: map[string]Column{
+		"account_id":   {Type: "int8", NumPrecision: 64, NotNull: true},
+		"method":       {Type: "varchar", CharLength: 255, NotNull: true},
+		"key":          {Type: "varchar", CharLength: 255, NotNull: true},
+		"request_hash": {Type: "bytea", NotNull: true},
+		"response":     {Type: "bytea"},
+		"completed":    {Type: "bool", NotNull: true},
+		"expires_at":   {Type: "timestamp", NotNull: true},
+	},
+	Indexes: map[string]Index{
+		"idempotency_key_pkey": {Columns: []string{"account_id", "method", "key"}, IsUnique: true},
+	},
+}
+
+// PostgresIdempotencyStore idempotency.Store backed by the idempotency_key table, call DeleteExpired periodically
+type PostgresIdempotencyStore struct {
+	db *sql.DB
+}
+
+func NewPostgresIdempotencyStore(db *sql.DB) *PostgresIdempotencyStore {
+	return &PostgresIdempotencyStore{
+		db: db,
+	}
+}
+
+func (s *PostgresIdempotencyStore) Reserve(ctx context.Context, key idempotency.Key, requestHash []byte, lockTimeout time.Duration) (*idempotency.Record, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	var accountId int64
+	err := s.db.QueryRowContext(ctx, queryReserveIdempotencyKey, int64(key.AccountId), key.Method, key.Key, requestHash,
+		now.Add(lockTimeout), now).Scan(&accountId)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("reserve idempotency key query failed: %w", err)
+	}
+
+	var record idempotency.Record
+	err = s.db.QueryRowContext(ctx, queryLoadIdempotencyKey, int64(key.AccountId), key.Method, key.Key).
+		Scan(&record.RequestHash, &record.Response, &record.Completed)
+	if errors.Is(err, sql.ErrNoRows) {
+		// released by the concurrent request right after the reserve attempt
+		return &idempotency.Record{RequestHash: requestHash}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load idempotency key query failed: %w", err)
+	}
+	return &record, nil
+}
+
+func (s *PostgresIdempotencyStore) Complete(ctx context.Context, key idempotency.Key, response []byte, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+	_, err := s.db.ExecContext(ctx, queryCompleteIdempotencyKey, int64(key.AccountId), key.Method, key.Key, response,
+		time.Now().UTC().Add(ttl))
+	if err != nil {
+		return fmt.Errorf("complete idempotency key query failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresIdempotencyStore) Release(ctx context.Context, key idempotency.Key) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+	if _, err := s.db.ExecContext(ctx, queryReleaseIdempotencyKey, int64(key.AccountId), key.Method, key.Key); err != nil {
+		return fmt.Errorf("release idempotency key query failed: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired deletes the expired records, returns the number of deleted records
+func (s *PostgresIdempotencyStore) DeleteExpired(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+	result, err := s.db.ExecContext(ctx, queryDeleteExpiredIdempotencyKeys, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("delete expired idempotency keys query failed: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,115 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/iyarkov/kit/auth"
+	"github.com/iyarkov/kit/support"
+	"time"
+)
+
+const (
+	revocationAll int16 = iota
+	revocationAccount
+	revocationGroup
+)
+
+var queryCreateRevocationTable = `
+CREATE TABLE auth_revocation (
+	kind SMALLINT NOT NULL,
+	subject_id BIGINT NOT NULL,
+	issued_before TIMESTAMP(3) WITHOUT TIME ZONE NOT NULL,
+	PRIMARY KEY (kind, subject_id)
+)`
+
+var queryUpsertRevocation = `
+INSERT INTO auth_revocation(kind, subject_id, issued_before) VALUES($1, $2, $3)
+ON CONFLICT (kind, subject_id) DO UPDATE SET issued_before = GREATEST(auth_revocation.issued_before, EXCLUDED.issued_before)`
+
+var queryLoadRevocations = "SELECT kind, subject_id, issued_before FROM auth_revocation"
+
+// RevocationChangeset creates the auth_revocation table, add it to the application changeset before calling Update
+var RevocationChangeset = []Change{
+	{
+		Version:  "kit.auth.revocation.1",
+		Commands: []string{queryCreateRevocationTable},
+	},
+}
+
+// RevocationTable expected auth_revocation table for Validate
+var RevocationTable = Table{
+	Columns: map[string]Column{
+		"kind":          {Type: "int2", NumPrecision: 16, NotNull: true},
+		"subject_id":    {Type: "int8", NumPrecision: 64, NotNull: true},
+		"issued_before": {Type: "timestamp", NotNull: true},
+	},
+	Indexes: map[string]Index{
+		"auth_revocation_pkey": {Columns: []string{"kind", "subject_id"}, IsUnique: true},
+	},
+}
+
+// PostgresRevocationStore auth.RevocationStore backed by the auth_revocation table
+type PostgresRevocationStore struct {
+	db *sql.DB
+}
+
+func NewPostgresRevocationStore(db *sql.DB) *PostgresRevocationStore {
+	return &PostgresRevocationStore{
+		db: db,
+	}
+}
+
+func (s *PostgresRevocationStore) RevokeAccount(ctx context.Context, accountId uint64, issuedBefore time.Time) error {
+	return s.revoke(ctx, revocationAccount, int64(accountId), issuedBefore)
+}
+
+func (s *PostgresRevocationStore) RevokeGroup(ctx context.Context, groupId uint32, issuedBefore time.Time) error {
+	return s.revoke(ctx, revocationGroup, int64(groupId), issuedBefore)
+}
+
+func (s *PostgresRevocationStore) RevokeIssuedBefore(ctx context.Context, issuedBefore time.Time) error {
+	return s.revoke(ctx, revocationAll, 0, issuedBefore)
+}
+
+func (s *PostgresRevocationStore) revoke(ctx context.Context, kind int16, subjectId int64, issuedBefore time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+	if _, err := s.db.ExecContext(ctx, queryUpsertRevocation, kind, subjectId, issuedBefore.UTC()); err != nil {
+		return fmt.Errorf("revoke query failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresRevocationStore) Load(ctx context.Context) (*auth.RevocationList, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, queryLoadRevocations)
+	defer support.CloseWithWarning(ctx, rows, "failed to close rows")
+	if err != nil {
+		return nil, fmt.Errorf("load revocations query failed: %w", err)
+	}
+
+	result := auth.NewRevocationList()
+	var kind int16
+	var subjectId int64
+	var issuedBefore time.Time
+	for rows.Next() {
+		if err = rows.Scan(&kind, &subjectId, &issuedBefore); err != nil {
+			return nil, fmt.Errorf("load revocations scan error: %w", err)
+		}
+		switch kind {
+		case revocationAll:
+			result.IssuedBefore = issuedBefore
+		case revocationAccount:
+			result.Accounts[uint64(subjectId)] = issuedBefore
+		case revocationGroup:
+			result.Groups[uint32(subjectId)] = issuedBefore
+		}
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("after scan error: %w", rows.Err())
+	}
+	return result, nil
+}
@@ -3,6 +3,8 @@ package sql
 import (
 	"context"
 	"database/sql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"os"
 	"testing"
 )
 
@@ -75,3 +77,48 @@ func TestAssertChangesetNoDuplicateId(t *testing.T) {
 		t.Error("Change without duplicated versions must be valid")
 	}
 }
+
+func TestRevocationChangeset(t *testing.T) {
+	if !assertChangeset(context.Background(), RevocationChangeset) {
+		t.Error("Revocation changeset must be valid")
+	}
+}
+
+func TestIdempotencyChangeset(t *testing.T) {
+	if !assertChangeset(context.Background(), IdempotencyChangeset) {
+		t.Error("Idempotency changeset must be valid")
+	}
+}
+
+// TestIdempotencyChangesetValidate applies the changeset to KIT_TEST_DATABASE_URL, the public schema is recreated so
+// the database must be disposable
+func TestIdempotencyChangesetValidate(t *testing.T) {
+	url := os.Getenv("KIT_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("KIT_TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	db, err := sql.Open("pgx", url)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	defer db.Close()
+	if err = RecreateSchema(ctx, db, defaultSchema); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if _, _, err = Update(ctx, db, IdempotencyChangeset); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	errors, err := Validate(ctx, db, Schema{
+		Name: defaultSchema,
+		Tables: map[string]Table{
+			"idempotency_key": IdempotencyTable,
+		},
+	}, true)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(errors) != 0 {
+		t.Errorf("Expecting the changeset to match IdempotencyTable, got %v", errors)
+	}
+}
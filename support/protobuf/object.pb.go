@@ -124,16 +124,19 @@ var File_iyarkov_kit_object_proto protoreflect.FileDescriptor
 var file_iyarkov_kit_object_proto_rawDesc = []byte{
 	0x0a, 0x18, 0x69, 0x79, 0x61, 0x72, 0x6b, 0x6f, 0x76, 0x2f, 0x6b, 0x69, 0x74, 0x2f, 0x6f, 0x62,
 	0x6a, 0x65, 0x63, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x69, 0x79, 0x61, 0x72,
-	0x6b, 0x6f, 0x76, 0x2e, 0x6b, 0x69, 0x74, 0x22, 0x1f, 0x0a, 0x0d, 0x4f, 0x62, 0x6a, 0x65, 0x63,
-	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x2a, 0x40, 0x0a, 0x0c, 0x4f, 0x62, 0x6a, 0x65,
-	0x63, 0x74, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0b, 0x0a, 0x07, 0x70, 0x65, 0x6e, 0x64,
-	0x69, 0x6e, 0x67, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x10,
-	0x01, 0x12, 0x0b, 0x0a, 0x07, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x10, 0x02, 0x12, 0x0a,
-	0x0a, 0x06, 0x70, 0x75, 0x72, 0x67, 0x65, 0x64, 0x10, 0x03, 0x42, 0x29, 0x5a, 0x27, 0x67, 0x69,
-	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x69, 0x79, 0x61, 0x72, 0x6b, 0x6f, 0x76,
-	0x2f, 0x6b, 0x69, 0x74, 0x2f, 0x73, 0x75, 0x70, 0x70, 0x6f, 0x72, 0x74, 0x2f, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x62, 0x75, 0x66, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x6b, 0x6f, 0x76, 0x2e, 0x6b, 0x69, 0x74, 0x1a, 0x1a, 0x69, 0x79, 0x61, 0x72, 0x6b, 0x6f, 0x76,
+	0x2f, 0x6b, 0x69, 0x74, 0x2f, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x22, 0x2a, 0x0a, 0x0d, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x42, 0x09, 0xc2, 0xf3, 0x18, 0x05, 0x18, 0xff, 0x01, 0x08, 0x01, 0x52, 0x02, 0x69, 0x64, 0x2a,
+	0x40, 0x0a, 0x0c, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12,
+	0x0b, 0x0a, 0x07, 0x70, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06,
+	0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x64, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x64, 0x10, 0x02, 0x12, 0x0a, 0x0a, 0x06, 0x70, 0x75, 0x72, 0x67, 0x65, 0x64, 0x10,
+	0x03, 0x42, 0x29, 0x5a, 0x27, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x69, 0x79, 0x61, 0x72, 0x6b, 0x6f, 0x76, 0x2f, 0x6b, 0x69, 0x74, 0x2f, 0x73, 0x75, 0x70, 0x70,
+	0x6f, 0x72, 0x74, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -167,6 +170,7 @@ func file_iyarkov_kit_object_proto_init() {
 	if File_iyarkov_kit_object_proto != nil {
 		return
 	}
+	file_iyarkov_kit_validate_proto_init()
 	if !protoimpl.UnsafeEnabled {
 		file_iyarkov_kit_object_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ObjectRequest); i {
@@ -2,9 +2,11 @@ package telemetry
 
 import (
 	"context"
+	"fmt"
 	"github.com/iyarkov/kit/logger"
 	"github.com/iyarkov/kit/support"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/sdk/resource"
 	semconv "go.opentelemetry.io/otel/semconv/v1.18.0"
 	"os"
@@ -31,6 +33,7 @@ func InitTelemetry(ctx context.Context, cfg *Configuration) {
 		metricNoOps()
 		traceNoOps()
 	}
+	otel.SetTextMapPropagator(Propagator)
 	support.OnSigTerm(func(shutdownCtx context.Context, signal os.Signal) {
 		shutdownCtx = logger.WithLogger(shutdownCtx)
 		shutdownMetric(shutdownCtx)
@@ -43,6 +46,18 @@ func Flush(ctx context.Context) {
 	traceFlush(ctx)
 }
 
+// Check health checker of the exporters, fails when the last export failed. It does not export, so frequent probes
+// do not load the collector
+func Check(ctx context.Context) error {
+	if err := metricExportState.lastError(); err != nil {
+		return fmt.Errorf("metric exporter failed: %w", err)
+	}
+	if err := traceExportState.lastError(); err != nil {
+		return fmt.Errorf("trace exporter failed: %w", err)
+	}
+	return nil
+}
+
 func newResource() *resource.Resource {
 	return resource.NewWithAttributes(
 		semconv.SchemaURL,
@@ -0,0 +1,54 @@
+package telemetry
+
+import (
+	"context"
+	sdk "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"sync"
+)
+
+// exportState result of the last export, reported by Check without exporting
+type exportState struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (s *exportState) record(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *exportState) lastError() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+var metricExportState exportState
+var traceExportState exportState
+
+// trackedSpanExporter records the result of the span exports
+type trackedSpanExporter struct {
+	sdktrace.SpanExporter
+	state *exportState
+}
+
+func (e trackedSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	e.state.record(err)
+	return err
+}
+
+// trackedMetricExporter records the result of the metric exports
+type trackedMetricExporter struct {
+	sdk.Exporter
+	state *exportState
+}
+
+func (e trackedMetricExporter) Export(ctx context.Context, metrics *metricdata.ResourceMetrics) error {
+	err := e.Exporter.Export(ctx, metrics)
+	e.state.record(err)
+	return err
+}
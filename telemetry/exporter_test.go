@@ -0,0 +1,37 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"testing"
+)
+
+type testSpanExporter struct {
+	sdktrace.SpanExporter
+	err error
+}
+
+func (e *testSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	return e.err
+}
+
+func TestCheck(t *testing.T) {
+	defer traceExportState.record(nil)
+	exporter := &testSpanExporter{err: errors.New("collector is down")}
+	tracked := trackedSpanExporter{SpanExporter: exporter, state: &traceExportState}
+	ctx := context.Background()
+
+	if err := Check(ctx); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	_ = tracked.ExportSpans(ctx, nil)
+	if err := Check(ctx); !errors.Is(err, exporter.err) {
+		t.Errorf("Expecting the export error, got %v", err)
+	}
+	exporter.err = nil
+	_ = tracked.ExportSpans(ctx, nil)
+	if err := Check(ctx); err != nil {
+		t.Errorf("Expecting the recovered exporter to pass, got %v", err)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/metric/noop"
 	sdk "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/aggregation"
 	"net/http"
 	"os"
 )
@@ -20,6 +21,24 @@ import (
 var Meter metric.Meter
 var metricProvider *sdk.MeterProvider
 
+// RPC histogram buckets, the SDK default buckets are too coarse for fast calls and do not fit message sizes
+var rpcDurationBuckets = []float64{0, 1, 2, 5, 10, 25, 50, 75, 100, 250, 500, 750, 1000, 2500, 5000, 10000}
+var rpcSizeBuckets = []float64{0, 128, 512, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304}
+var rpcCountBuckets = []float64{0, 1, 2, 5, 10, 25, 50, 100, 250, 500, 1000}
+
+func rpcViews() []sdk.View {
+	view := func(name string, boundaries []float64) sdk.View {
+		return sdk.NewView(sdk.Instrument{Name: name}, sdk.Stream{
+			Aggregation: aggregation.ExplicitBucketHistogram{Boundaries: boundaries},
+		})
+	}
+	return []sdk.View{
+		view("rpc.*.duration", rpcDurationBuckets),
+		view("rpc.*.size", rpcSizeBuckets),
+		view("rpc.*_per_rpc", rpcCountBuckets),
+	}
+}
+
 func metricNoOps() {
 	Meter = noop.NewMeterProvider().Meter("application")
 }
@@ -36,7 +55,8 @@ func metricConsole(ctx context.Context) {
 	// Register the exporter with an SDK via a periodic reader.
 	metricProvider = sdk.NewMeterProvider(
 		sdk.WithResource(newResource()),
-		sdk.WithReader(sdk.NewPeriodicReader(exporter)),
+		sdk.WithReader(sdk.NewPeriodicReader(trackedMetricExporter{Exporter: exporter, state: &metricExportState})),
+		sdk.WithView(rpcViews()...),
 	)
 	Meter = metricProvider.Meter("meter")
 	log.Info().Msg("stdout metric exporter initialized")
@@ -71,7 +91,7 @@ func metricDocker(ctx context.Context) {
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to initialize prometheus metric exporter")
 	}
-	metricProvider = sdk.NewMeterProvider(sdk.WithReader(exporter))
+	metricProvider = sdk.NewMeterProvider(sdk.WithReader(exporter), sdk.WithView(rpcViews()...))
 	Meter = metricProvider.Meter(support.AppManifest.Name)
 
 	go func() {
@@ -91,6 +111,7 @@ func metricDocker(ctx context.Context) {
 		})
 		listenErr := srv.ListenAndServe()
 		if listenErr != nil && !errors.Is(listenErr, http.ErrServerClosed) {
+			metricExportState.record(listenErr)
 			zerolog.Ctx(ctx).Error().Err(listenErr).Msg("metrics HTTP server failed to start")
 		}
 	}()
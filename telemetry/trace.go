@@ -7,6 +7,7 @@ import (
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
 	sdk "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -14,14 +15,30 @@ import (
 var tracer trace.Tracer
 var tracerProvider *sdk.TracerProvider
 
+// Propagator W3C trace context and baggage, used to pass the trace across gRPC calls and NATS messages
+var Propagator propagation.TextMapPropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
 func WithSpan(ctx context.Context, name string, f func(context.Context) error) error {
 	ctx, span := tracer.Start(ctx, name)
 	defer span.End()
 	return f(ctx)
 }
 
-func StartSpan(ctx context.Context, name string) (context.Context, trace.Span) {
-	return tracer.Start(ctx, name)
+func StartSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, opts...)
+}
+
+// Inject writes the span context and baggage of ctx into the carrier
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	Propagator.Inject(ctx, carrier)
+}
+
+// Extract returns ctx with the remote span context and baggage read from the carrier
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return Propagator.Extract(ctx, carrier)
 }
 
 func SpanFromContext(ctx context.Context) trace.Span {
@@ -41,7 +58,7 @@ func traceConsole(ctx context.Context) {
 	}
 
 	tracerProvider = sdk.NewTracerProvider(
-		sdk.WithBatcher(exporter),
+		sdk.WithBatcher(trackedSpanExporter{SpanExporter: exporter, state: &traceExportState}),
 		sdk.WithResource(newResource()),
 	)
 	otel.SetTracerProvider(tracerProvider)
@@ -74,7 +91,7 @@ func traceDocker(ctx context.Context) {
 	}
 
 	tracerProvider = sdk.NewTracerProvider(
-		sdk.WithBatcher(exporter),
+		sdk.WithBatcher(trackedSpanExporter{SpanExporter: exporter, state: &traceExportState}),
 		sdk.WithResource(newResource()),
 	)
 	otel.SetTracerProvider(tracerProvider)
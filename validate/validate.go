@@ -0,0 +1,198 @@
+package validate
+
+import (
+	"fmt"
+	kiterrors "github.com/iyarkov/kit/errors"
+	"github.com/iyarkov/kit/support/protobuf"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"regexp"
+	"sync"
+	"unicode/utf8"
+)
+
+// rules and patterns are parsed once per field
+var fieldRules sync.Map
+var patterns sync.Map
+
+// Message validates the message and the nested messages against the iyarkov.kit.rules field options, returns
+// errors.ValidationError with a violation per invalid field, nil if the message is valid
+func Message(msg proto.Message) error {
+	violations, err := validateMessage(msg.ProtoReflect(), "", nil)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return kiterrors.Validation(violations...)
+	}
+	return nil
+}
+
+func rulesOf(fd protoreflect.FieldDescriptor) *protobuf.FieldRules {
+	if cached, ok := fieldRules.Load(fd.FullName()); ok {
+		return cached.(*protobuf.FieldRules)
+	}
+	var rules *protobuf.FieldRules
+	if options := fd.Options(); options != nil && proto.HasExtension(options, protobuf.E_Rules) {
+		rules = proto.GetExtension(options, protobuf.E_Rules).(*protobuf.FieldRules)
+	}
+	fieldRules.Store(fd.FullName(), rules)
+	return rules
+}
+
+func patternOf(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := patterns.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, compiled)
+	return compiled, nil
+}
+
+func validateMessage(m protoreflect.Message, prefix string, violations []kiterrors.FieldViolation) ([]kiterrors.FieldViolation, error) {
+	fields := m.Descriptor().Fields()
+	var err error
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		path := prefix + string(fd.Name())
+		if rules := rulesOf(fd); rules != nil {
+			if violations, err = validateField(m, fd, rules, path, violations); err != nil {
+				return nil, err
+			}
+		}
+		if violations, err = validateNested(m, fd, path, violations); err != nil {
+			return nil, err
+		}
+	}
+	return violations, nil
+}
+
+func validateNested(m protoreflect.Message, fd protoreflect.FieldDescriptor, path string, violations []kiterrors.FieldViolation) ([]kiterrors.FieldViolation, error) {
+	if !m.Has(fd) {
+		return violations, nil
+	}
+	var err error
+	switch {
+	case fd.IsList():
+		if fd.Message() == nil {
+			return violations, nil
+		}
+		list := m.Get(fd).List()
+		for i := 0; i < list.Len() && err == nil; i++ {
+			violations, err = validateMessage(list.Get(i).Message(), fmt.Sprintf("%s[%d].", path, i), violations)
+		}
+	case fd.IsMap():
+		if fd.MapValue().Message() == nil {
+			return violations, nil
+		}
+		m.Get(fd).Map().Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+			violations, err = validateMessage(value.Message(), fmt.Sprintf("%s[%v].", path, key.Interface()), violations)
+			return err == nil
+		})
+	case fd.Message() != nil:
+		violations, err = validateMessage(m.Get(fd).Message(), path+".", violations)
+	}
+	return violations, err
+}
+
+func validateField(m protoreflect.Message, fd protoreflect.FieldDescriptor, rules *protobuf.FieldRules, path string, violations []kiterrors.FieldViolation) ([]kiterrors.FieldViolation, error) {
+	violation := func(format string, args ...any) {
+		violations = append(violations, kiterrors.FieldViolation{
+			Field:       path,
+			Description: fmt.Sprintf(format, args...),
+		})
+	}
+	switch {
+	case fd.IsList():
+		list := m.Get(fd).List()
+		checkLength(rules.Required, rules.MinLen, rules.MaxLen, list.Len(), "items", violation)
+		var err error
+		for i := 0; i < list.Len() && err == nil; i++ {
+			violations, err = validateValue(fd, list.Get(i), rules, fmt.Sprintf("%s[%d]", path, i), violations)
+		}
+		return violations, err
+	case fd.IsMap():
+		checkLength(rules.Required, rules.MinLen, rules.MaxLen, m.Get(fd).Map().Len(), "items", violation)
+		return violations, nil
+	case fd.Message() != nil:
+		if rules.Required && !m.Has(fd) {
+			violation("is required")
+		}
+		return violations, nil
+	}
+	if rules.Required && !m.Has(fd) {
+		violation("is required")
+		return violations, nil
+	}
+	if fd.HasPresence() && !m.Has(fd) {
+		return violations, nil
+	}
+	return validateValue(fd, m.Get(fd), rules, path, violations)
+}
+
+func checkLength(required bool, minLen, maxLen *uint32, length int, unit string, violation func(format string, args ...any)) {
+	if required && length == 0 {
+		violation("is required")
+		return
+	}
+	if minLen != nil && length < int(*minLen) {
+		violation("must have at least %d %s", *minLen, unit)
+	}
+	if maxLen != nil && length > int(*maxLen) {
+		violation("must have at most %d %s", *maxLen, unit)
+	}
+}
+
+func validateValue(fd protoreflect.FieldDescriptor, value protoreflect.Value, rules *protobuf.FieldRules, path string, violations []kiterrors.FieldViolation) ([]kiterrors.FieldViolation, error) {
+	violation := func(format string, args ...any) {
+		violations = append(violations, kiterrors.FieldViolation{
+			Field:       path,
+			Description: fmt.Sprintf(format, args...),
+		})
+	}
+	// required and the list lengths are checked by the caller
+	minLen, maxLen := rules.MinLen, rules.MaxLen
+	if fd.IsList() {
+		minLen, maxLen = nil, nil
+	}
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		text := value.String()
+		checkLength(false, minLen, maxLen, utf8.RuneCountInString(text), "characters", violation)
+		if rules.Pattern != "" {
+			pattern, err := patternOf(rules.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: invalid pattern: %w", fd.FullName(), err)
+			}
+			if !pattern.MatchString(text) {
+				violation("must match %s", rules.Pattern)
+			}
+		}
+	case protoreflect.BytesKind:
+		checkLength(false, minLen, maxLen, len(value.Bytes()), "bytes", violation)
+	case protoreflect.EnumKind:
+		if rules.DefinedOnly && fd.Enum().Values().ByNumber(value.Enum()) == nil {
+			violation("must be a defined value")
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		checkRange(rules, float64(value.Int()), violation)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		checkRange(rules, float64(value.Uint()), violation)
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		checkRange(rules, value.Float(), violation)
+	}
+	return violations, nil
+}
+
+func checkRange(rules *protobuf.FieldRules, number float64, violation func(format string, args ...any)) {
+	if rules.Min != nil && number < rules.GetMin() {
+		violation("must be greater than or equal to %v", rules.GetMin())
+	}
+	if rules.Max != nil && number > rules.GetMax() {
+		violation("must be less than or equal to %v", rules.GetMax())
+	}
+}
@@ -0,0 +1,153 @@
+package validate
+
+import (
+	"errors"
+	kiterrors "github.com/iyarkov/kit/errors"
+	"github.com/iyarkov/kit/support/protobuf"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"reflect"
+	"testing"
+)
+
+func violations(t *testing.T, err error) map[string]string {
+	t.Helper()
+	result := make(map[string]string)
+	if err == nil {
+		return result
+	}
+	var validationError *kiterrors.ValidationError
+	if !errors.As(err, &validationError) {
+		t.Fatalf("Expecting a validation error, got %v", err)
+	}
+	for _, violation := range validationError.Violations {
+		result[violation.Field] = violation.Description
+	}
+	return result
+}
+
+func TestKitMessages(t *testing.T) {
+	if err := Message(&protobuf.PageRequest{Limit: 100, Direction: protobuf.SortOrder_desc}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	actual := violations(t, Message(&protobuf.PageRequest{Limit: 1001, Direction: 5}))
+	expected := map[string]string{
+		"limit":     "must be less than or equal to 1000",
+		"direction": "must be a defined value",
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expecting %v, got %v", expected, actual)
+	}
+
+	if err := Message(&protobuf.ObjectRequest{Id: "42"}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	actual = violations(t, Message(&protobuf.ObjectRequest{}))
+	if actual["id"] != "is required" {
+		t.Errorf("Expecting id is required, got %v", actual)
+	}
+}
+
+func rulesOption(rules *protobuf.FieldRules) *descriptorpb.FieldOptions {
+	options := &descriptorpb.FieldOptions{}
+	proto.SetExtension(options, protobuf.E_Rules, rules)
+	return options
+}
+
+// testMessage message Item { string code = 1 [pattern]; } message Order { string name = 1 [min_len 2, max_len 4];
+// int64 amount = 2 [min -1, max 10]; repeated string tags = 3 [max_len 2, pattern]; Item item = 4 [required];
+// repeated Item items = 5; bytes data = 6 [required]; optional double ratio = 7 [max 1] }
+func testMessage(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+	label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test/validate.proto"),
+		Package: proto.String("test.validate"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Item"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("code"), Number: proto.Int32(1), Label: label, Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Options: rulesOption(&protobuf.FieldRules{Pattern: "^[A-Z]+$"})},
+				},
+			},
+			{
+				Name: proto.String("Order"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("name"), Number: proto.Int32(1), Label: label, Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Options: rulesOption(&protobuf.FieldRules{MinLen: proto.Uint32(2), MaxLen: proto.Uint32(4)})},
+					{Name: proto.String("amount"), Number: proto.Int32(2), Label: label, Type: descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum(),
+						Options: rulesOption(&protobuf.FieldRules{Min: proto.Float64(-1), Max: proto.Float64(10)})},
+					{Name: proto.String("tags"), Number: proto.Int32(3), Label: repeated, Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Options: rulesOption(&protobuf.FieldRules{MaxLen: proto.Uint32(2), Pattern: "^[a-z]+$"})},
+					{Name: proto.String("item"), Number: proto.Int32(4), Label: label, Type: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".test.validate.Item"), Options: rulesOption(&protobuf.FieldRules{Required: true})},
+					{Name: proto.String("items"), Number: proto.Int32(5), Label: repeated, Type: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".test.validate.Item")},
+					{Name: proto.String("data"), Number: proto.Int32(6), Label: label, Type: descriptorpb.FieldDescriptorProto_TYPE_BYTES.Enum(),
+						Options: rulesOption(&protobuf.FieldRules{Required: true})},
+					{Name: proto.String("ratio"), Number: proto.Int32(7), Label: label, Type: descriptorpb.FieldDescriptorProto_TYPE_DOUBLE.Enum(),
+						Proto3Optional: proto.Bool(true), OneofIndex: proto.Int32(0), Options: rulesOption(&protobuf.FieldRules{Max: proto.Float64(1)})},
+				},
+				OneofDecl: []*descriptorpb.OneofDescriptorProto{{Name: proto.String("_ratio")}},
+			},
+		},
+	}
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	return fd.Messages().ByName("Order")
+}
+
+func TestRules(t *testing.T) {
+	descriptor := testMessage(t)
+	fields := descriptor.Fields()
+	newItem := func(code string) protoreflect.Value {
+		item := dynamicpb.NewMessage(fields.ByName("item").Message())
+		item.Set(item.Descriptor().Fields().ByName("code"), protoreflect.ValueOfString(code))
+		return protoreflect.ValueOfMessage(item)
+	}
+
+	valid := dynamicpb.NewMessage(descriptor)
+	valid.Set(fields.ByName("name"), protoreflect.ValueOfString("abc"))
+	valid.Set(fields.ByName("amount"), protoreflect.ValueOfInt64(10))
+	valid.Mutable(fields.ByName("tags")).List().Append(protoreflect.ValueOfString("tag"))
+	valid.Set(fields.ByName("item"), newItem("OK"))
+	valid.Set(fields.ByName("data"), protoreflect.ValueOfBytes([]byte{1}))
+	if err := Message(valid); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	invalid := dynamicpb.NewMessage(descriptor)
+	invalid.Set(fields.ByName("name"), protoreflect.ValueOfString("abcde"))
+	invalid.Set(fields.ByName("amount"), protoreflect.ValueOfInt64(-2))
+	tags := invalid.Mutable(fields.ByName("tags")).List()
+	tags.Append(protoreflect.ValueOfString("a"))
+	tags.Append(protoreflect.ValueOfString("B"))
+	tags.Append(protoreflect.ValueOfString("c"))
+	items := invalid.Mutable(fields.ByName("items")).List()
+	items.Append(newItem("OK"))
+	items.Append(newItem("bad"))
+	invalid.Set(fields.ByName("ratio"), protoreflect.ValueOfFloat64(1.5))
+
+	expected := map[string]string{
+		"name":          "must have at most 4 characters",
+		"amount":        "must be greater than or equal to -1",
+		"tags":          "must have at most 2 items",
+		"tags[1]":       "must match ^[a-z]+$",
+		"item":          "is required",
+		"items[1].code": "must match ^[A-Z]+$",
+		"data":          "is required",
+		"ratio":         "must be less than or equal to 1",
+	}
+	if actual := violations(t, Message(invalid)); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expecting %v, got %v", expected, actual)
+	}
+}